@@ -0,0 +1,191 @@
+// Package betterstack handles communicating with status pages hosted on Better Stack
+package betterstack
+
+import (
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "betterstack"
+
+// States reported by Better Stack for the page, its resources, and its reports
+const (
+	StateOperational = "operational"
+	StateDegraded    = "degraded"
+	StateDowntime    = "downtime"
+	StateMaintenance = "maintenance"
+)
+
+// Types of the resources included with the status page
+const (
+	TypeResource = "status_page_resource"
+	TypeReport   = "status_report"
+)
+
+// Indicator maps a Better Stack state onto our severities
+func Indicator(state string) string {
+	switch state {
+	case StateDowntime:
+		return "major"
+	case StateDegraded:
+		return "minor"
+	case StateMaintenance:
+		return status.IndicatorMaintenance
+	default:
+		return "none"
+	}
+}
+
+// Attributes holds the fields we use from the page and the resources included with it
+type Attributes struct {
+	// CompanyName and CompanyURL describe the page
+	CompanyName string `json:"company_name"`
+	CompanyURL  string `json:"company_url"`
+	// AggregateState is the state of the page or of a status report
+	AggregateState string    `json:"aggregate_state"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// PublicName and Status describe a monitored resource
+	PublicName string `json:"public_name"`
+	Status     string `json:"status"`
+	// Title, StartsAt, and EndsAt describe a status report; ongoing reports have no end
+	Title    string     `json:"title"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// Resource is a JSON:API resource
+type Resource struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Attributes Attributes `json:"attributes"`
+}
+
+// Response is what Better Stack returns for /index.json
+type Response struct {
+	Data     Resource   `json:"data"`
+	Included []Resource `json:"included"`
+
+	name      string
+	pageURL   string
+	updatedAt time.Time
+}
+
+// Indicator returns the most severe of the page state and the states of its resources
+func (r Response) Indicator() string {
+	state := r.Data.Attributes.AggregateState
+	for _, res := range r.Included {
+		if res.Type == TypeResource && worse(res.Attributes.Status, state) {
+			state = res.Attributes.Status
+		}
+	}
+
+	return Indicator(state)
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when the page or its latest report changed; it is zero when neither is known
+func (r Response) UpdatedAt() time.Time {
+	return r.updatedAt
+}
+
+// URL returns the status page
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists the monitored resources with their state, then the titles of the ongoing reports
+func (r Response) Summary() []string {
+	var lines []string
+	for _, res := range r.Included {
+		if res.Type == TypeResource && res.Attributes.PublicName != "" {
+			lines = append(lines, res.Attributes.PublicName+": "+stateText(res.Attributes.Status))
+		}
+	}
+	for _, res := range r.Included {
+		if res.Type == TypeReport && res.Attributes.EndsAt == nil {
+			lines = append(lines, "Incident: "+res.Attributes.Title)
+		}
+	}
+
+	return lines
+}
+
+// latest returns when the page or one of its reports last changed
+func (r Response) latest() time.Time {
+	latest := r.Data.Attributes.UpdatedAt
+	for _, res := range r.Included {
+		if res.Type == TypeReport && res.Attributes.StartsAt.After(latest) {
+			latest = res.Attributes.StartsAt
+		}
+	}
+
+	return latest
+}
+
+// severity orders the states from least to most severe
+var severity = map[string]int{
+	StateOperational: 0,
+	StateMaintenance: 1,
+	StateDegraded:    2,
+	StateDowntime:    3,
+}
+
+func worse(state, than string) bool {
+	return severity[state] > severity[than]
+}
+
+func stateText(state string) string {
+	switch state {
+	case StateDegraded:
+		return "Degraded"
+	case StateDowntime:
+		return "Downtime"
+	case StateMaintenance:
+		return "Maintenance"
+	default:
+		return "Operational"
+	}
+}
+
+// ClientReader implements the Reader interface for Better Stack pages, e.g. https://status.example.com/index.json
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Response
+
+	err = g.GetJSON(cr.PageURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Data.Attributes.AggregateState == "" {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, cr.PageURL+" did not report a status")
+	}
+
+	resp.name = cr.ServiceName
+	resp.pageURL = cr.PageURL
+
+	resp.updatedAt = resp.latest()
+
+	return resp, nil
+}
@@ -0,0 +1,102 @@
+package betterstack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Indicator(t *testing.T) {
+	tests := map[string]string{
+		StateOperational: "none",
+		StateMaintenance: status.IndicatorMaintenance,
+		StateDegraded:    "minor",
+		StateDowntime:    "major",
+		"unknown":        "none",
+	}
+	for state, expected := range tests {
+		require.Equal(t, expected, Indicator(state), "state %s", state)
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- degraded": {
+			reader: ClientReader{ServiceName: "Acme", PageURL: "https://status.acme.dev/index.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, "Acme", details.Name())
+				require.Equal(t, "https://status.acme.dev/index.json", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 20, 0, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"API: Degraded",
+					"Dashboard: Operational",
+					"Incident: Slow API responses",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- operational": {
+			reader: ClientReader{ServiceName: "Widgets", PageURL: "https://status.widgets.io/index.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Equal(t, time.Date(2024, time.February, 28, 9, 0, 0, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{"Website: Operational"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- resource down while the page reports operational": {
+			reader: ClientReader{ServiceName: "Gadgets", PageURL: "https://status.gadgets.app/index.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, []string{"Checkout: Downtime", "Search: Maintenance"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://status.unknown.dev/index.json"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://status.unknown.dev/index.json"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Acme", PageURL: "https://status.acme.dev/index.json"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"data":{"attributes":{}}}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	details, err = reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"data":{"attributes":{"aggregate_state":"operational"}}}`)))
+	require.NoError(t, err)
+	require.True(t, details.UpdatedAt().IsZero())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
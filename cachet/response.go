@@ -0,0 +1,341 @@
+// Package cachet handles communicating with self-hosted Cachet status pages
+package cachet
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "cachet"
+
+// ErrorUnknownComponentGroup is returned when a configured component group is not on the page
+const ErrorUnknownComponentGroup = "UNKNOWN_COMPONENT_GROUP"
+
+// Component statuses reported by Cachet
+const (
+	ComponentOperational       = 1
+	ComponentPerformanceIssues = 2
+	ComponentPartialOutage     = 3
+	ComponentMajorOutage       = 4
+)
+
+// Incident statuses reported by Cachet; scheduled and fixed incidents are not open
+const (
+	IncidentScheduled     = 0
+	IncidentInvestigating = 1
+	IncidentIdentified    = 2
+	IncidentWatching      = 3
+	IncidentFixed         = 4
+)
+
+// API paths, relative to where Cachet is installed
+const (
+	componentsPath      = "/api/v1/components"
+	componentGroupsPath = "/api/v1/components/groups"
+	incidentsPath       = "/api/v1/incidents"
+)
+
+// maxPages stops us following the pagination of a very large page forever
+const maxPages = 10
+
+// Indicator maps a Cachet component status onto our severities
+func Indicator(code int) string {
+	switch code {
+	case ComponentMajorOutage:
+		return "major"
+	case ComponentPerformanceIssues, ComponentPartialOutage:
+		return "minor"
+	default:
+		return "none"
+	}
+}
+
+// Time reads the timestamps Cachet writes without a time zone, e.g. 2024-03-04 14:52:11, as well as RFC 3339 ones.
+// Cachet writes them in the time zone of its install and its API does not say which one that is, so we read them as
+// UTC; an install set to another time zone shows its dates off by its offset.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON handles converting data into the Time type
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+
+	err := json.Unmarshal(data, &s)
+	if err != nil || s == "" {
+		return err
+	}
+
+	parsed, pErr := time.Parse(time.DateTime, s)
+	if pErr != nil {
+		parsed, pErr = time.Parse(time.RFC3339, s)
+		if pErr != nil {
+			return pErr
+		}
+	}
+
+	t.Time = parsed
+
+	return nil
+}
+
+// Component is a part of the service
+type Component struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Status     int    `json:"status"`
+	StatusName string `json:"status_name"`
+	GroupID    int    `json:"group_id"`
+	Enabled    bool   `json:"enabled"`
+	UpdatedAt  Time   `json:"updated_at"`
+}
+
+// Group is a set of components
+type Group struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Incident is an incident reported on the page
+type Incident struct {
+	ID          int    `json:"id"`
+	ComponentID int    `json:"component_id"`
+	Name        string `json:"name"`
+	Status      int    `json:"status"`
+	HumanStatus string `json:"human_status"`
+	UpdatedAt   Time   `json:"updated_at"`
+}
+
+// Open reports whether the incident is still being worked on
+func (i Incident) Open() bool {
+	return i.Status >= IncidentInvestigating && i.Status <= IncidentWatching
+}
+
+// Response holds the components and open incidents of a Cachet page
+type Response struct {
+	Components []Component
+	Incidents  []Incident
+
+	name    string
+	pageURL string
+}
+
+// Indicator returns the most severe status of the components; an open incident not reflected by any component counts
+// as minor
+func (r Response) Indicator() string {
+	worst := ComponentOperational
+	for _, c := range r.Components {
+		if c.Status > worst && c.Status <= ComponentMajorOutage {
+			worst = c.Status
+		}
+	}
+
+	if worst == ComponentOperational && len(r.Incidents) > 0 {
+		return "minor"
+	}
+
+	return Indicator(worst)
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when a component or open incident last changed
+func (r Response) UpdatedAt() time.Time {
+	var latest time.Time
+	for _, c := range r.Components {
+		if c.UpdatedAt.After(latest) {
+			latest = c.UpdatedAt.Time
+		}
+	}
+	for _, i := range r.Incidents {
+		if i.UpdatedAt.After(latest) {
+			latest = i.UpdatedAt.Time
+		}
+	}
+
+	return latest
+}
+
+// URL returns the status page
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists the components with their status, then the names of the open incidents
+func (r Response) Summary() []string {
+	lines := make([]string, 0, len(r.Components)+len(r.Incidents))
+	for _, c := range r.Components {
+		lines = append(lines, c.Name+": "+c.StatusName)
+	}
+	for _, i := range r.Incidents {
+		lines = append(lines, "Incident: "+i.Name)
+	}
+
+	return lines
+}
+
+// page is one page of a Cachet API listing
+type page[T any] struct {
+	Meta struct {
+		Pagination struct {
+			Links struct {
+				NextPage string `json:"next_page"`
+			} `json:"links"`
+		} `json:"pagination"`
+	} `json:"meta"`
+	Data []T `json:"data"`
+}
+
+// ClientReader implements the Reader interface for Cachet pages. PageURL is where Cachet is installed, e.g.
+// https://status.example.com.
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+	// ComponentGroups limits the page to the components in these groups, by name or ID
+	ComponentGroups []string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(strings.TrimSuffix(cr.PageURL, "/"), "/api/v1")
+
+	components, err := list[Component](g, base+componentsPath+"?per_page=100", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(components) == 0 {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, base+componentsPath+" did not list any components")
+	}
+
+	incidents, err := list[Incident](g, base+incidentsPath+"?sort=id&order=desc&per_page=50", unresolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cr.ComponentGroups) > 0 {
+		groups, gErr := list[Group](g, base+componentGroupsPath+"?per_page=100", nil)
+		if gErr != nil {
+			return nil, gErr
+		}
+
+		ids, gErr := cr.groupIDs(groups)
+		if gErr != nil {
+			return nil, gErr
+		}
+
+		components = filterComponents(components, ids)
+	}
+
+	resp := Response{name: cr.ServiceName, pageURL: base}
+	for _, c := range components {
+		if c.Enabled {
+			resp.Components = append(resp.Components, c)
+		}
+	}
+
+	// Incidents not tied to a component affect the whole page
+	inPage := map[int]bool{0: true}
+	for _, c := range resp.Components {
+		inPage[c.ID] = true
+	}
+	for _, i := range incidents {
+		if i.Open() && inPage[i.ComponentID] {
+			resp.Incidents = append(resp.Incidents, i)
+		}
+	}
+
+	return resp, nil
+}
+
+// groupIDs returns the IDs of the configured component groups
+func (cr ClientReader) groupIDs(groups []Group) (map[int]bool, glitch.DataError) {
+	ids := map[int]bool{}
+	for _, want := range cr.ComponentGroups {
+		found := false
+		for _, g := range groups {
+			if strings.EqualFold(g.Name, want) || strconv.Itoa(g.ID) == want {
+				ids[g.ID] = true
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, glitch.NewDataError(nil, ErrorUnknownComponentGroup, cr.ServiceName+" has no component group "+want)
+		}
+	}
+
+	return ids, nil
+}
+
+func filterComponents(components []Component, groupIDs map[int]bool) []Component {
+	var filtered []Component
+	for _, c := range components {
+		if groupIDs[c.GroupID] {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered
+}
+
+// unresolved reports whether none of the incidents on a page have been fixed. Incidents are listed newest first, so
+// once a page reaches a fixed incident, the rest of the history is older still and we stop reading it.
+func unresolved(incidents []Incident) bool {
+	for _, i := range incidents {
+		if i.Status == IncidentFixed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// list reads the pages of a Cachet API listing, following its pagination links on the same host for as long as more
+// reports the page just read may be followed by items we need; every page is read when more is nil
+func list[T any](g fetch.Getter, pageURL string, more func(page []T) bool) ([]T, glitch.DataError) {
+	first, pErr := url.Parse(pageURL)
+	if pErr != nil {
+		return nil, glitch.NewDataError(pErr, fetch.ErrorUnableToParseResponse, "invalid Cachet URL "+pageURL)
+	}
+
+	var items []T
+	for n := 0; n < maxPages && pageURL != ""; n++ {
+		var p page[T]
+
+		err := g.GetJSON(pageURL, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, p.Data...)
+
+		pageURL = ""
+		if more != nil && !more(p.Data) {
+			break
+		}
+		if next, nErr := url.Parse(p.Meta.Pagination.Links.NextPage); nErr == nil && next.Host == first.Host && next.Path != "" {
+			pageURL = next.String()
+		}
+	}
+
+	return items, nil
+}
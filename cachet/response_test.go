@@ -0,0 +1,221 @@
+package cachet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// newCachet starts a stand-in for a Cachet install with two component groups, its components split over two pages
+func newCachet(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var body string
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case componentsPath + "?per_page=100":
+			body = `{"meta":{"pagination":{"total":4,"count":3,"per_page":3,"current_page":1,"total_pages":2,"links":{"next_page":"` + srv.URL + componentsPath + `?page=2&per_page=100","previous_page":null}}},"data":[
+				{"id":1,"name":"API","status":3,"status_name":"Partial Outage","group_id":1,"enabled":true,"updated_at":"2024-03-04 14:30:00"},
+				{"id":2,"name":"Dashboard","status":1,"status_name":"Operational","group_id":1,"enabled":true,"updated_at":"2024-03-01 09:00:00"},
+				{"id":3,"name":"Legacy API","status":4,"status_name":"Major Outage","group_id":1,"enabled":false,"updated_at":"2024-03-04 15:00:00"}]}`
+		case componentsPath + "?page=2&per_page=100":
+			body = `{"meta":{"pagination":{"links":{"next_page":null}}},"data":[
+				{"id":4,"name":"Build Runners","status":1,"status_name":"Operational","group_id":2,"enabled":true,"updated_at":"2024-03-02 10:00:00"}]}`
+		case componentGroupsPath + "?per_page=100":
+			body = `{"meta":{"pagination":{"links":{"next_page":null}}},"data":[{"id":1,"name":"Platform"},{"id":2,"name":"CI"}]}`
+		case incidentsPath + "?sort=id&order=desc&per_page=50":
+			body = `{"meta":{"pagination":{"links":{"next_page":null}}},"data":[
+				{"id":12,"component_id":1,"name":"Elevated API errors","status":2,"human_status":"Identified","updated_at":"2024-03-04 14:45:00"},
+				{"id":11,"component_id":4,"name":"Slow builds","status":3,"human_status":"Watching","updated_at":"2024-03-03 08:00:00"},
+				{"id":10,"component_id":2,"name":"Dashboard login failures","status":4,"human_status":"Fixed","updated_at":"2024-03-01 09:00:00"},
+				{"id":9,"component_id":0,"name":"Database upgrade","status":0,"human_status":"Scheduled","updated_at":"2024-02-28 12:00:00"}]}`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestUnit_Indicator(t *testing.T) {
+	tests := map[int]string{
+		ComponentOperational:       "none",
+		ComponentPerformanceIssues: "minor",
+		ComponentPartialOutage:     "minor",
+		ComponentMajorOutage:       "major",
+		0:                          "none",
+	}
+	for code, expected := range tests {
+		require.Equal(t, expected, Indicator(code), "status %d", code)
+	}
+}
+
+func TestUnit_Response_Indicator(t *testing.T) {
+	tests := map[string]struct {
+		resp     Response
+		expected string
+	}{
+		"base path- operational": {
+			resp:     Response{Components: []Component{{Status: ComponentOperational}}},
+			expected: "none",
+		},
+		"base path- worst component": {
+			resp:     Response{Components: []Component{{Status: ComponentPerformanceIssues}, {Status: ComponentMajorOutage}}},
+			expected: "major",
+		},
+		"base path- open incident with operational components": {
+			resp:     Response{Components: []Component{{Status: ComponentOperational}}, Incidents: []Incident{{Status: IncidentInvestigating}}},
+			expected: "minor",
+		},
+		"exceptional path- unknown status": {
+			resp:     Response{Components: []Component{{Status: 9}}},
+			expected: "none",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.resp.Indicator())
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	srv := newCachet(t)
+	client := fetch.NewClient(srv.Client())
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- every component": {
+			reader: ClientReader{ServiceName: "Internal", PageURL: srv.URL + "/"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, "Internal", details.Name())
+				require.Equal(t, srv.URL, details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 45, 0, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"API: Partial Outage",
+					"Dashboard: Operational",
+					"Build Runners: Operational",
+					"Incident: Elevated API errors",
+					"Incident: Slow builds",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- component group by name": {
+			reader: ClientReader{ServiceName: "CI", PageURL: srv.URL + "/api/v1", ComponentGroups: []string{"ci"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, []string{"Build Runners: Operational", "Incident: Slow builds"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- component group by ID": {
+			reader: ClientReader{ServiceName: "Platform", PageURL: srv.URL, ComponentGroups: []string{"1"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, []string{"API: Partial Outage", "Dashboard: Operational", "Incident: Elevated API errors"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"exceptional path- unknown component group": {
+			reader:      ClientReader{ServiceName: "Internal", PageURL: srv.URL, ComponentGroups: []string{"Billing"}},
+			expectedErr: glitch.NewDataError(nil, ErrorUnknownComponentGroup, "Internal has no component group Billing"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Equal(t, expectedErr, actualErr)
+				require.Nil(t, details)
+			},
+		},
+		"exceptional path- not a Cachet install": {
+			reader:      ClientReader{ServiceName: "Internal", PageURL: srv.URL + "/elsewhere"},
+			expectedErr: glitch.NewDataError(nil, fetch.ErrorUnexpectedStatusCode, ""),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_IncidentPages(t *testing.T) {
+	var requested []string
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		requested = append(requested, r.URL.Path+"?"+r.URL.RawQuery)
+
+		var body string
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case componentsPath + "?per_page=100":
+			body = `{"meta":{"pagination":{"links":{"next_page":null}}},"data":[{"id":1,"name":"API","status":1,"status_name":"Operational","enabled":true}]}`
+		case incidentsPath + "?sort=id&order=desc&per_page=50":
+			body = `{"meta":{"pagination":{"links":{"next_page":"` + srv.URL + incidentsPath + `?sort=id&order=desc&per_page=50&page=2"}}},"data":[
+				{"id":30,"component_id":1,"name":"Elevated API errors","status":1,"human_status":"Investigating"}]}`
+		case incidentsPath + "?sort=id&order=desc&per_page=50&page=2":
+			body = `{"meta":{"pagination":{"links":{"next_page":"` + srv.URL + incidentsPath + `?sort=id&order=desc&per_page=50&page=3"}}},"data":[
+				{"id":29,"component_id":1,"name":"Slow API","status":3,"human_status":"Watching"},
+				{"id":28,"component_id":1,"name":"API outage","status":4,"human_status":"Fixed"}]}`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	details, err := ClientReader{ServiceName: "Internal", PageURL: srv.URL}.ReadStatus(fetch.NewClient(srv.Client()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"API: Operational", "Incident: Elevated API errors", "Incident: Slow API"}, details.(status.Summarizer).Summary())
+
+	// The history past the first fixed incident is not read
+	require.Equal(t, []string{
+		componentsPath + "?per_page=100",
+		incidentsPath + "?sort=id&order=desc&per_page=50",
+		incidentsPath + "?sort=id&order=desc&per_page=50&page=2",
+	}, requested)
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Internal", PageURL: "https://status.example.com"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"data":[]}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	details, err = reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"data":[{"id":1,"updated_at":"yesterday"}]}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
@@ -0,0 +1,33 @@
+// Package main runs a local fake status page server for developing and demoing the plugin
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fakeserver"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8787", "address to listen on")
+	dir := flag.String("dir", "fakeserver/scenarios", "directory holding the scenario files")
+	scenario := flag.String("scenario", "default", "name of the scenario to start with")
+	flag.Parse()
+
+	sc, err := fakeserver.LoadScenario(configuration.FileReader{}, filepath.Join(*dir, *scenario+".json"))
+	if err != nil {
+		log.Fatalf("unable to load scenario %s: %v", *scenario, err)
+	}
+
+	log.Printf("serving scenario %s on http://%s", *scenario, *addr)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           fakeserver.New(*dir, sc),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Fatal(srv.ListenAndServe())
+}
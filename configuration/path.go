@@ -0,0 +1,145 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvVar names the environment variable holding the path of the configuration file
+const EnvVar = "WHATS_UP_CONFIG"
+
+// Flag is the command line flag holding the path of the configuration file, given as --config path or --config=path
+const Flag = "--config"
+
+// File names we look for
+const (
+	// Filename is the configuration file kept next to the plugin
+	Filename = ".whats-up.json"
+	// DirName is the directory holding the configuration under the user's configuration directory
+	DirName = "whats-up"
+	// DirFilename is the configuration file inside DirName
+	DirFilename = "config.json"
+)
+
+// Finder looks for the configuration file. The lookup functions are fields so tests do not depend on the machine
+// they run on; NewFinder fills them in from the os package.
+type Finder struct {
+	Getenv     func(key string) string
+	Executable func() (string, error)
+	// EvalSymlinks resolves the executable when the plugin is a symlink, e.g. into a checkout of the repository
+	EvalSymlinks func(path string) (string, error)
+	UserHomeDir  func() (string, error)
+	Exists       func(path string) bool
+}
+
+// NewFinder returns a finder looking at the actual environment and file system
+func NewFinder() Finder {
+	return Finder{
+		Getenv:       os.Getenv,
+		Executable:   os.Executable,
+		EvalSymlinks: filepath.EvalSymlinks,
+		UserHomeDir:  os.UserHomeDir,
+		Exists: func(path string) bool {
+			info, err := os.Stat(path)
+			return err == nil && !info.IsDir()
+		},
+	}
+}
+
+// explicit returns the path given through the flag or the environment variable, if any
+func (f Finder) explicit(flagPath string) string {
+	if flagPath != "" {
+		return flagPath
+	}
+
+	return f.Getenv(EnvVar)
+}
+
+// Candidates returns every path we look at, in order: the --config flag, the WHATS_UP_CONFIG environment variable,
+// the directory of the plugin executable and, when the plugin is a symlink, the directory it points into,
+// $XDG_CONFIG_HOME/whats-up, and ~/.config/whats-up. A path given through the flag or the environment variable is the
+// only candidate.
+func (f Finder) Candidates(flagPath string) []string {
+	if p := f.explicit(flagPath); p != "" {
+		return []string{p}
+	}
+
+	var candidates []string
+
+	if exe, err := f.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), Filename))
+
+		// The plugins directory keeps its own configuration even when the plugin is linked in from elsewhere
+		if f.EvalSymlinks != nil {
+			if resolved, rErr := f.EvalSymlinks(exe); rErr == nil && filepath.Dir(resolved) != filepath.Dir(exe) {
+				candidates = append(candidates, filepath.Join(filepath.Dir(resolved), Filename))
+			}
+		}
+	}
+
+	if xdg := f.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, DirName, DirFilename))
+	}
+
+	if home, err := f.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", DirName, DirFilename))
+	}
+
+	return candidates
+}
+
+// Find returns the first candidate that exists, or false when there is none
+func (f Finder) Find(flagPath string) (string, bool) {
+	for _, p := range f.Candidates(flagPath) {
+		if f.Exists(p) {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// Default returns where a new configuration file is created: the explicit path if one was given, otherwise the user's
+// configuration directory
+func (f Finder) Default(flagPath string) string {
+	if p := f.explicit(flagPath); p != "" {
+		return p
+	}
+
+	candidates := f.Candidates("")
+	for _, c := range candidates {
+		if filepath.Base(c) == DirFilename {
+			return c
+		}
+	}
+
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+
+	return Filename
+}
+
+// ParseArgs pulls the --config flag out of the arguments, returning its value and the remaining arguments
+func ParseArgs(args []string) (string, []string) {
+	var path string
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == Flag || a == "-config":
+			if i+1 < len(args) {
+				path = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(a, Flag+"=") || strings.HasPrefix(a, "-config="):
+			_, path, _ = strings.Cut(a, "=")
+		default:
+			rest = append(rest, a)
+		}
+	}
+
+	return path, rest
+}
@@ -0,0 +1,182 @@
+package configuration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Finder(t *testing.T) {
+	tests := map[string]struct {
+		flagPath           string
+		env                map[string]string
+		exists             []string
+		noExecutable       bool
+		symlinkTarget      string
+		expectedCandidates []string
+		expectedPath       string
+		expectedFound      bool
+		expectedDefault    string
+	}{
+		"base path- flag wins": {
+			flagPath:           "/tmp/flag.json",
+			env:                map[string]string{EnvVar: "/tmp/env.json"},
+			exists:             []string{"/tmp/flag.json", "/tmp/env.json"},
+			expectedCandidates: []string{"/tmp/flag.json"},
+			expectedPath:       "/tmp/flag.json",
+			expectedFound:      true,
+			expectedDefault:    "/tmp/flag.json",
+		},
+		"base path- environment variable": {
+			env:                map[string]string{EnvVar: "/tmp/env.json"},
+			exists:             []string{"/tmp/env.json"},
+			expectedCandidates: []string{"/tmp/env.json"},
+			expectedPath:       "/tmp/env.json",
+			expectedFound:      true,
+			expectedDefault:    "/tmp/env.json",
+		},
+		"base path- next to the plugin": {
+			env:    map[string]string{"XDG_CONFIG_HOME": "/home/me/.xdg"},
+			exists: []string{"/plugins/.whats-up.json", "/home/me/.xdg/whats-up/config.json"},
+			expectedCandidates: []string{
+				"/plugins/.whats-up.json",
+				"/home/me/.xdg/whats-up/config.json",
+				"/home/me/.config/whats-up/config.json",
+			},
+			expectedPath:    "/plugins/.whats-up.json",
+			expectedFound:   true,
+			expectedDefault: "/home/me/.xdg/whats-up/config.json",
+		},
+		"base path- plugin symlinked into the plugins directory": {
+			exists:        []string{"/plugins/.whats-up.json", "/src/xbar-whats-up/.whats-up.json"},
+			symlinkTarget: "/src/xbar-whats-up/whats-up.1h.cgo",
+			expectedCandidates: []string{
+				"/plugins/.whats-up.json",
+				"/src/xbar-whats-up/.whats-up.json",
+				"/home/me/.config/whats-up/config.json",
+			},
+			expectedPath:    "/plugins/.whats-up.json",
+			expectedFound:   true,
+			expectedDefault: "/home/me/.config/whats-up/config.json",
+		},
+		"base path- next to the file the plugin links to": {
+			exists:          []string{"/src/xbar-whats-up/.whats-up.json"},
+			symlinkTarget:   "/src/xbar-whats-up/whats-up.1h.cgo",
+			expectedPath:    "/src/xbar-whats-up/.whats-up.json",
+			expectedFound:   true,
+			expectedDefault: "/home/me/.config/whats-up/config.json",
+		},
+		"base path- XDG configuration directory": {
+			env:             map[string]string{"XDG_CONFIG_HOME": "/home/me/.xdg"},
+			exists:          []string{"/home/me/.xdg/whats-up/config.json", "/home/me/.config/whats-up/config.json"},
+			expectedPath:    "/home/me/.xdg/whats-up/config.json",
+			expectedFound:   true,
+			expectedDefault: "/home/me/.xdg/whats-up/config.json",
+		},
+		"base path- home configuration directory": {
+			exists:          []string{"/home/me/.config/whats-up/config.json"},
+			noExecutable:    true,
+			expectedPath:    "/home/me/.config/whats-up/config.json",
+			expectedFound:   true,
+			expectedDefault: "/home/me/.config/whats-up/config.json",
+		},
+		"exceptional path- explicit path does not exist": {
+			flagPath:        "/tmp/missing.json",
+			exists:          []string{"/plugins/.whats-up.json"},
+			expectedFound:   false,
+			expectedDefault: "/tmp/missing.json",
+		},
+		"exceptional path- nothing found": {
+			expectedFound:   false,
+			expectedDefault: "/home/me/.config/whats-up/config.json",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := Finder{
+				Getenv: func(key string) string { return tc.env[key] },
+				Executable: func() (string, error) {
+					if tc.noExecutable {
+						return "", errors.New("no executable")
+					}
+					return "/plugins/whats-up.1h", nil
+				},
+				EvalSymlinks: func(path string) (string, error) {
+					if tc.symlinkTarget != "" {
+						return tc.symlinkTarget, nil
+					}
+					return path, nil
+				},
+				UserHomeDir: func() (string, error) { return "/home/me", nil },
+				Exists: func(path string) bool {
+					for _, e := range tc.exists {
+						if e == path {
+							return true
+						}
+					}
+					return false
+				},
+			}
+
+			if tc.expectedCandidates != nil {
+				require.Equal(t, tc.expectedCandidates, f.Candidates(tc.flagPath))
+			}
+
+			path, found := f.Find(tc.flagPath)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expectedPath, path)
+			require.Equal(t, tc.expectedDefault, f.Default(tc.flagPath))
+		})
+	}
+}
+
+func TestUnit_NewFinder_Symlink(t *testing.T) {
+	// Resolve the temporary directories themselves, e.g. /var on macOS, so only the plugin is a symlink
+	checkout, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	plugins, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "whats-up.1h.cgo"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, Filename), []byte(`{}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(plugins, Filename), []byte(`{}`), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(checkout, "whats-up.1h.cgo"), filepath.Join(plugins, "whats-up.1h.cgo")))
+
+	f := NewFinder()
+	f.Getenv = func(string) string { return "" }
+	f.Executable = func() (string, error) { return filepath.Join(plugins, "whats-up.1h.cgo"), nil }
+
+	path, found := f.Find("")
+	require.True(t, found)
+	require.Equal(t, filepath.Join(plugins, Filename), path)
+
+	require.NoError(t, os.Remove(filepath.Join(plugins, Filename)))
+
+	path, found = f.Find("")
+	require.True(t, found)
+	require.Equal(t, filepath.Join(checkout, Filename), path)
+}
+
+func TestUnit_ParseArgs(t *testing.T) {
+	tests := map[string]struct {
+		args         []string
+		expectedPath string
+		expectedArgs []string
+	}{
+		"base path- no flag":         {args: []string{"stats"}, expectedArgs: []string{"stats"}},
+		"base path- separate value":  {args: []string{"--config", "/tmp/c.json", "stats"}, expectedPath: "/tmp/c.json", expectedArgs: []string{"stats"}},
+		"base path- joined value":    {args: []string{"stats", "--config=/tmp/c.json"}, expectedPath: "/tmp/c.json", expectedArgs: []string{"stats"}},
+		"base path- single dash":     {args: []string{"-config", "/tmp/c.json"}, expectedPath: "/tmp/c.json"},
+		"exceptional path- no value": {args: []string{"--config"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, args := ParseArgs(tc.args)
+			require.Equal(t, tc.expectedPath, path)
+			require.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package debug sets up the structured logs written while debugging the plugin
+package debug
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Settings read from the environment; xbar lets us set environment variables for a plugin in its settings
+const (
+	// EnvVar turns debugging on: 1, true, or text for text logs, or json for JSON logs
+	EnvVar = "WHATSUP_DEBUG"
+	// FileEnvVar overrides where the logs are written
+	FileEnvVar = "WHATSUP_DEBUG_FILE"
+)
+
+// DefaultFilename is the file the logs are written to, next to the configuration, unless overridden
+const DefaultFilename = ".whats-up.debug.log"
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Flag is the command line flag turning debugging on; --debug=json selects JSON logs
+const Flag = "--debug"
+
+// ParseArgs looks for the debug flag in the arguments, falling back to the environment. It returns the log format, or
+// an empty string when debugging is off, along with the arguments minus the flag.
+func ParseArgs(args []string, getenv func(string) string) (string, []string) {
+	format := formatFor(getenv(EnvVar))
+
+	var rest []string
+	for _, a := range args {
+		switch {
+		case a == Flag || a == "-debug":
+			format = FormatText
+		case strings.HasPrefix(a, Flag+"=") || strings.HasPrefix(a, "-debug="):
+			_, v, _ := strings.Cut(a, "=")
+			format = formatFor(v)
+		default:
+			rest = append(rest, a)
+		}
+	}
+
+	return format, rest
+}
+
+func formatFor(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "0", "false", "off":
+		return ""
+	case FormatJSON:
+		return FormatJSON
+	default:
+		return FormatText
+	}
+}
+
+// NewLogger returns a logger writing every level in the given format
+func NewLogger(w io.Writer, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Open returns a logger appending to the debug log file named by the environment, or to the default file in the
+// directory; the file has to be closed once done
+func Open(format, dir string, getenv func(string) string) (*slog.Logger, io.Closer, error) {
+	filename := getenv(FileEnvVar)
+	if filename == "" {
+		filename = filepath.Join(dir, DefaultFilename)
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewLogger(f, format), f, nil
+}
@@ -0,0 +1,78 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_ParseArgs(t *testing.T) {
+	tests := map[string]struct {
+		args           []string
+		env            string
+		expectedFormat string
+		expectedArgs   []string
+	}{
+		"base path- off":                   {args: []string{"stats"}, expectedArgs: []string{"stats"}},
+		"base path- flag":                  {args: []string{"--debug"}, expectedFormat: FormatText},
+		"base path- flag with format":      {args: []string{"stats", "--debug=json", "-format", "csv"}, expectedFormat: FormatJSON, expectedArgs: []string{"stats", "-format", "csv"}},
+		"base path- environment":           {env: "1", expectedFormat: FormatText},
+		"base path- environment with JSON": {env: "JSON", expectedFormat: FormatJSON},
+		"base path- environment off":       {env: "false"},
+		"base path- flag overrides env":    {args: []string{"--debug=json"}, env: "text", expectedFormat: FormatJSON},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			getenv := func(key string) string {
+				if key == EnvVar {
+					return tc.env
+				}
+				return ""
+			}
+
+			format, args := ParseArgs(tc.args, getenv)
+			require.Equal(t, tc.expectedFormat, format)
+			require.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
+
+func TestUnit_Open(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "debug.log")
+	getenv := func(key string) string {
+		if key == FileEnvVar {
+			return filename
+		}
+		return ""
+	}
+
+	for i := 0; i < 2; i++ {
+		logger, closer, err := Open(FormatJSON, t.TempDir(), getenv)
+		require.NoError(t, err)
+		logger.Debug("request", "url", "https://status.example.com", "status", 200)
+		require.NoError(t, closer.Close())
+	}
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[1], &entry))
+	require.Equal(t, "DEBUG", entry["level"])
+	require.Equal(t, "request", entry["msg"])
+	require.Equal(t, float64(200), entry["status"])
+
+	dir := t.TempDir()
+	logger, closer, err := Open(FormatText, dir, func(string) string { return "" })
+	require.NoError(t, err)
+	logger.Info("cache")
+	require.NoError(t, closer.Close())
+	require.FileExists(t, filepath.Join(dir, DefaultFilename))
+}
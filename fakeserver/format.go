@@ -0,0 +1,351 @@
+package fakeserver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sprak3000/xbar-whats-up/internal/mapkeys"
+)
+
+// page holds what every format needs to render a fake service
+type page struct {
+	Key       string
+	Path      string
+	URL       string
+	UpdatedAt time.Time
+	Service
+}
+
+// renderer builds the response body of a fake service in a given format
+type renderer func(p page) interface{}
+
+// renderers maps each service type to how its status page looks
+var renderers = map[string]renderer{
+	"statuspage.io": renderStatuspageIo,
+	"slack":         renderSlack,
+	"statusio":      renderStatusIo,
+	"instatus":      renderInstatus,
+	"betterstack":   renderBetterStack,
+	"cachet":        renderCachet,
+	"googlecloud":   renderGoogleCloud,
+	"uptimekuma":    renderUptimeKuma,
+	"gatus":         renderGatus,
+}
+
+// Formats returns the service types the fake server can serve
+func Formats() []string {
+	return mapkeys.Sorted(renderers)
+}
+
+func renderStatuspageIo(p page) interface{} {
+	descriptions := map[string]string{
+		StatusNone:        "All Systems Operational",
+		StatusMinor:       "Minor Service Outage",
+		StatusMajor:       "Partial System Outage",
+		StatusMaintenance: "Service Under Maintenance",
+	}
+
+	return map[string]interface{}{
+		"page": map[string]interface{}{
+			"id":         p.Key,
+			"name":       p.Name,
+			"url":        p.URL,
+			"time_zone":  "Etc/UTC",
+			"updated_at": p.UpdatedAt,
+		},
+		"status": map[string]interface{}{
+			"indicator":   p.Status,
+			"description": descriptions[p.Status],
+		},
+	}
+}
+
+func renderSlack(p page) interface{} {
+	incidents := []interface{}{}
+	status := "ok"
+
+	if p.Status != StatusNone {
+		status = "active"
+
+		incidentType := "incident"
+		switch p.Status {
+		case StatusMajor:
+			incidentType = "outage"
+		case StatusMaintenance:
+			incidentType = "maintenance"
+		}
+
+		incidents = append(incidents, map[string]interface{}{
+			"id":           1,
+			"date_created": p.UpdatedAt,
+			"date_updated": p.UpdatedAt,
+			"title":        p.incidentTitle(),
+			"type":         incidentType,
+			"status":       "active",
+			"url":          p.URL,
+			"services":     []string{"Messaging"},
+		})
+	}
+
+	return map[string]interface{}{
+		"status":           status,
+		"date_created":     p.UpdatedAt,
+		"date_updated":     p.UpdatedAt,
+		"active_incidents": incidents,
+	}
+}
+
+func renderStatusIo(p page) interface{} {
+	statuses := map[string]struct {
+		code        int
+		description string
+	}{
+		StatusNone:        {100, "Operational"},
+		StatusMinor:       {300, "Degraded Performance"},
+		StatusMajor:       {500, "Service Disruption"},
+		StatusMaintenance: {200, "Planned Maintenance"},
+	}
+	s := statuses[p.Status]
+
+	incidents := []interface{}{}
+	if p.Status != StatusNone && p.Status != StatusMaintenance {
+		incidents = append(incidents, map[string]interface{}{"_id": "1", "name": p.incidentTitle()})
+	}
+
+	status := map[string]interface{}{
+		"id":          p.Key,
+		"name":        p.Name,
+		"updated":     p.UpdatedAt,
+		"status":      s.description,
+		"status_code": s.code,
+	}
+
+	return map[string]interface{}{
+		"result": map[string]interface{}{
+			"status_overall": status,
+			"status": []interface{}{
+				map[string]interface{}{
+					"id":          p.Key + "-api",
+					"name":        "API",
+					"updated":     p.UpdatedAt,
+					"status":      s.description,
+					"status_code": s.code,
+					"containers": []interface{}{
+						map[string]interface{}{"id": p.Key + "-us", "name": "US", "updated": p.UpdatedAt, "status": s.description, "status_code": s.code},
+					},
+				},
+			},
+			"incidents": incidents,
+		},
+	}
+}
+
+func renderInstatus(p page) interface{} {
+	pageStatus := "UP"
+	incidents := []interface{}{}
+	maintenances := []interface{}{}
+
+	switch p.Status {
+	case StatusMinor, StatusMajor:
+		pageStatus = "HASISSUES"
+
+		impact := "PARTIALOUTAGE"
+		if p.Status == StatusMajor {
+			impact = "MAJOROUTAGE"
+		}
+
+		incidents = append(incidents, map[string]interface{}{
+			"id":      p.Key + "-incident",
+			"name":    p.incidentTitle(),
+			"started": p.UpdatedAt,
+			"status":  "INVESTIGATING",
+			"impact":  impact,
+			"url":     p.URL,
+		})
+	case StatusMaintenance:
+		pageStatus = "UNDERMAINTENANCE"
+		maintenances = append(maintenances, map[string]interface{}{
+			"id":     p.Key + "-maintenance",
+			"name":   p.incidentTitle(),
+			"start":  p.UpdatedAt,
+			"status": "INPROGRESS",
+			"url":    p.URL,
+		})
+	}
+
+	return map[string]interface{}{
+		"page": map[string]interface{}{
+			"name":   p.Name,
+			"url":    p.URL,
+			"status": pageStatus,
+		},
+		"activeIncidents":    incidents,
+		"activeMaintenances": maintenances,
+	}
+}
+
+func renderBetterStack(p page) interface{} {
+	states := map[string]string{
+		StatusNone:        "operational",
+		StatusMinor:       "degraded",
+		StatusMajor:       "downtime",
+		StatusMaintenance: "maintenance",
+	}
+	state := states[p.Status]
+
+	included := []interface{}{
+		map[string]interface{}{
+			"id":   p.Key + "-api",
+			"type": "status_page_resource",
+			"attributes": map[string]interface{}{
+				"public_name": "API",
+				"status":      state,
+			},
+		},
+	}
+
+	if p.Status != StatusNone {
+		included = append(included, map[string]interface{}{
+			"id":   p.Key + "-report",
+			"type": "status_report",
+			"attributes": map[string]interface{}{
+				"title":           p.incidentTitle(),
+				"aggregate_state": state,
+				"starts_at":       p.UpdatedAt,
+				"ends_at":         nil,
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"data": map[string]interface{}{
+			"id":   p.Key,
+			"type": "status_page",
+			"attributes": map[string]interface{}{
+				"company_name":    p.Name,
+				"company_url":     p.URL,
+				"aggregate_state": state,
+				"updated_at":      p.UpdatedAt,
+			},
+		},
+		"included": included,
+	}
+}
+
+// renderCachet answers the component, component group, and incident listings of the Cachet API under the service path
+func renderCachet(p page) interface{} {
+	statuses := map[string]struct {
+		code int
+		name string
+	}{
+		StatusNone:  {1, "Operational"},
+		StatusMinor: {3, "Partial Outage"},
+		StatusMajor: {4, "Major Outage"},
+		// Cachet has no maintenance status for components
+		StatusMaintenance: {2, "Performance Issues"},
+	}
+	s := statuses[p.Status]
+	updatedAt := p.UpdatedAt.UTC().Format(time.DateTime)
+
+	data := []interface{}{}
+	switch {
+	case strings.HasSuffix(p.Path, "/components/groups"):
+		data = append(data, map[string]interface{}{"id": 1, "name": "Services"})
+	case strings.HasSuffix(p.Path, "/components"):
+		data = append(data, map[string]interface{}{
+			"id":          1,
+			"name":        "API",
+			"status":      s.code,
+			"status_name": s.name,
+			"group_id":    1,
+			"enabled":     true,
+			"updated_at":  updatedAt,
+		})
+	case strings.HasSuffix(p.Path, "/incidents") && p.Status != StatusNone:
+		data = append(data, map[string]interface{}{
+			"id":           1,
+			"component_id": 1,
+			"name":         p.incidentTitle(),
+			"status":       1,
+			"human_status": "Investigating",
+			"updated_at":   updatedAt,
+		})
+	}
+
+	return map[string]interface{}{
+		"meta": map[string]interface{}{"pagination": map[string]interface{}{"links": map[string]interface{}{"next_page": nil}}},
+		"data": data,
+	}
+}
+
+func renderGoogleCloud(p page) interface{} {
+	severities := map[string]string{
+		StatusMinor:       "medium",
+		StatusMajor:       "high",
+		StatusMaintenance: "low",
+	}
+
+	incident := map[string]interface{}{
+		"id":                           p.Key,
+		"begin":                        p.UpdatedAt,
+		"modified":                     p.UpdatedAt,
+		"external_desc":                p.incidentTitle(),
+		"severity":                     severities[p.Status],
+		"service_name":                 p.Name,
+		"affected_products":            []interface{}{map[string]interface{}{"id": p.Key, "title": p.Name}},
+		"currently_affected_locations": []interface{}{map[string]interface{}{"id": "us-central1", "title": "Iowa (us-central1)"}},
+	}
+	if p.Status == StatusNone {
+		incident["end"] = p.UpdatedAt
+	}
+
+	return []interface{}{incident}
+}
+
+// renderUptimeKuma answers both the status page and its heartbeats under the service path
+func renderUptimeKuma(p page) interface{} {
+	heartbeats := map[string]int{
+		StatusNone:        1,
+		StatusMinor:       2,
+		StatusMajor:       0,
+		StatusMaintenance: 3,
+	}
+
+	if strings.Contains(p.Path, "/heartbeat/") {
+		return map[string]interface{}{
+			"heartbeatList": map[string]interface{}{
+				"1": []interface{}{
+					map[string]interface{}{"status": heartbeats[p.Status], "time": p.UpdatedAt.UTC().Format("2006-01-02 15:04:05.000"), "msg": ""},
+				},
+			},
+			"uptimeList": map[string]interface{}{},
+		}
+	}
+
+	return map[string]interface{}{
+		"config": map[string]interface{}{"slug": p.Key, "title": p.Name},
+		"publicGroupList": []interface{}{
+			map[string]interface{}{
+				"id":          1,
+				"name":        "Services",
+				"monitorList": []interface{}{map[string]interface{}{"id": 1, "name": "API"}},
+			},
+		},
+		"maintenanceList": []interface{}{},
+	}
+}
+
+func renderGatus(p page) interface{} {
+	healthy := p.Status != StatusMajor
+
+	return []interface{}{
+		map[string]interface{}{
+			"name":  "api",
+			"group": p.Name,
+			"key":   p.Key + "_api",
+			"results": []interface{}{
+				map[string]interface{}{"status": 200, "success": healthy, "timestamp": p.UpdatedAt},
+			},
+		},
+	}
+}
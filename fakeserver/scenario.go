@@ -0,0 +1,58 @@
+// Package fakeserver serves fake status pages from scenario files so the plugin can be driven end-to-end without
+// waiting on a real outage
+package fakeserver
+
+import "github.com/sprak3000/xbar-whats-up/fetch"
+
+// Behaviors a fake service can exhibit besides reporting its status
+const (
+	// BehaviorServerError responds with a 500
+	BehaviorServerError = "error"
+	// BehaviorMalformed responds with a body that is not valid JSON
+	BehaviorMalformed = "malformed"
+)
+
+// Statuses a fake service can report; every format maps them onto its own vocabulary
+const (
+	StatusNone        = "none"
+	StatusMinor       = "minor"
+	StatusMajor       = "major"
+	StatusMaintenance = "maintenance"
+)
+
+// Service describes a single fake status page
+type Service struct {
+	// Format is the service type of the page, e.g. statuspage.io or slack
+	Format string `json:"format"`
+	// Name is the page name reported in formats that carry one; the service key is used if empty
+	Name string `json:"name"`
+	// Status is one of none, minor, major, or maintenance
+	Status string `json:"status"`
+	// Incident is the title of the incident reported when the status is not none
+	Incident string `json:"incident"`
+	// Delay slows the response down, e.g. "5s"
+	Delay fetch.Duration `json:"delay"`
+	// Behavior makes the page misbehave: error or malformed
+	Behavior string `json:"behavior"`
+}
+
+// Scenario is a set of fake services keyed by the first path segment they are served under
+type Scenario struct {
+	Services map[string]Service `json:"services"`
+}
+
+// incidentTitle returns the incident title to report for the service
+func (s Service) incidentTitle() string {
+	if s.Incident != "" {
+		return s.Incident
+	}
+
+	switch s.Status {
+	case StatusMaintenance:
+		return "Scheduled maintenance"
+	case StatusMajor:
+		return "Major outage"
+	default:
+		return "Degraded performance"
+	}
+}
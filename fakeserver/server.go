@@ -0,0 +1,263 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+// AdminPrefix is the path the admin endpoints are served under
+const AdminPrefix = "/admin/"
+
+// Server serves the fake status pages of the current scenario. The scenario can be switched at runtime through the
+// admin endpoints:
+//
+//	GET  /admin/scenario          returns the current scenario
+//	PUT  /admin/scenario          replaces the current scenario with the request body
+//	POST /admin/scenarios/{name}  loads {name}.json from the scenario directory
+//	PUT  /admin/services/{key}    adds or replaces a single service with the request body
+//	DELETE /admin/services/{key}  removes a single service
+type Server struct {
+	// Dir is where named scenarios are loaded from
+	Dir    string
+	Reader configuration.Reader
+
+	mu        sync.RWMutex
+	scenario  Scenario
+	updatedAt map[string]time.Time
+}
+
+// New returns a server for the scenario; named scenarios are loaded from the directory
+func New(dir string, scenario Scenario) *Server {
+	s := &Server{
+		Dir:    dir,
+		Reader: configuration.FileReader{},
+	}
+	s.setScenario(scenario)
+
+	return s
+}
+
+// LoadScenario reads a scenario file
+func LoadScenario(r configuration.Reader, filename string) (Scenario, error) {
+	var scenario Scenario
+
+	data, err := r.ReadFile(filename)
+	if err != nil {
+		return scenario, err
+	}
+
+	err = json.Unmarshal(data, &scenario)
+	if err != nil {
+		return scenario, fmt.Errorf("unable to parse scenario %s: %w", filename, err)
+	}
+
+	return scenario, scenario.validate()
+}
+
+func (sc Scenario) validate() error {
+	for key, svc := range sc.Services {
+		if err := svc.validate(); err != nil {
+			return fmt.Errorf("service %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (s Service) validate() error {
+	if _, ok := renderers[s.Format]; !ok {
+		return fmt.Errorf("unsupported format %q, expected one of %s", s.Format, strings.Join(Formats(), ", "))
+	}
+
+	switch s.Status {
+	case "", StatusNone, StatusMinor, StatusMajor, StatusMaintenance:
+	default:
+		return fmt.Errorf("unsupported status %q", s.Status)
+	}
+
+	switch s.Behavior {
+	case "", BehaviorServerError, BehaviorMalformed:
+	default:
+		return fmt.Errorf("unsupported behavior %q", s.Behavior)
+	}
+
+	return nil
+}
+
+// Scenario returns a copy of the current scenario
+func (s *Server) Scenario() Scenario {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	services := make(map[string]Service, len(s.scenario.Services))
+	for k, v := range s.scenario.Services {
+		services[k] = v
+	}
+
+	return Scenario{Services: services}
+}
+
+func (s *Server) setScenario(scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if scenario.Services == nil {
+		scenario.Services = map[string]Service{}
+	}
+
+	now := time.Now().UTC()
+	s.scenario = scenario
+	s.updatedAt = map[string]time.Time{}
+	for k := range scenario.Services {
+		s.updatedAt[k] = now
+	}
+}
+
+func (s *Server) setService(key string, svc *Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if svc == nil {
+		delete(s.scenario.Services, key)
+		delete(s.updatedAt, key)
+		return
+	}
+
+	s.scenario.Services[key] = *svc
+	s.updatedAt[key] = time.Now().UTC()
+}
+
+// ServeHTTP serves the admin endpoints and the fake status pages
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, AdminPrefix) {
+		s.serveAdmin(w, r)
+		return
+	}
+
+	key, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.RLock()
+	svc, ok := s.scenario.Services[key]
+	updatedAt := s.updatedAt[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if svc.Delay > 0 {
+		select {
+		case <-time.After(time.Duration(svc.Delay)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch svc.Behavior {
+	case BehaviorServerError:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	case BehaviorMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"page":{"name":"` + key + `",`))
+		return
+	}
+
+	if svc.Name == "" {
+		svc.Name = key
+	}
+	if svc.Status == "" {
+		svc.Status = StatusNone
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	body := renderers[svc.Format](page{
+		Key:       key,
+		Path:      r.URL.Path,
+		URL:       scheme + "://" + r.Host + "/" + key,
+		UpdatedAt: updatedAt,
+		Service:   svc,
+	})
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, AdminPrefix)
+
+	switch {
+	case path == "scenario" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.Scenario())
+	case path == "scenario" && r.Method == http.MethodPut:
+		var scenario Scenario
+		if !decodeBody(w, r, &scenario) {
+			return
+		}
+		if err := scenario.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.setScenario(scenario)
+		writeJSON(w, http.StatusOK, s.Scenario())
+	case strings.HasPrefix(path, "scenarios/") && r.Method == http.MethodPost:
+		name := strings.TrimPrefix(path, "scenarios/")
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			http.Error(w, "invalid scenario name", http.StatusBadRequest)
+			return
+		}
+		scenario, err := LoadScenario(s.Reader, filepath.Join(s.Dir, name+".json"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.setScenario(scenario)
+		writeJSON(w, http.StatusOK, s.Scenario())
+	case strings.HasPrefix(path, "services/") && r.Method == http.MethodPut:
+		var svc Service
+		if !decodeBody(w, r, &svc) {
+			return
+		}
+		if err := svc.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.setService(strings.TrimPrefix(path, "services/"), &svc)
+		writeJSON(w, http.StatusOK, s.Scenario())
+	case strings.HasPrefix(path, "services/") && r.Method == http.MethodDelete:
+		s.setService(strings.TrimPrefix(path, "services/"), nil)
+		writeJSON(w, http.StatusOK, s.Scenario())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		http.Error(w, "unable to parse request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,124 @@
+package fakeserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/service"
+	"github.com/sprak3000/xbar-whats-up/slack"
+	"github.com/sprak3000/xbar-whats-up/statuspageio"
+)
+
+func TestUnit_Server_EndToEnd(t *testing.T) {
+	sc, err := LoadScenario(configuration.FileReader{}, "scenarios/default.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(New("scenarios", sc))
+	defer srv.Close()
+
+	siteURL := func(path string) url.URL {
+		u, pErr := url.Parse(srv.URL + path)
+		require.NoError(t, pErr)
+		return *u
+	}
+
+	sites := service.Sites{
+		"CircleCI": {URL: siteURL("/circleci/api/v2/status.json"), Type: statuspageio.ServiceType},
+		"GitHub":   {URL: siteURL("/github/api/v2/status.json"), Type: statuspageio.ServiceType},
+		"Slack":    {URL: siteURL("/slack/api/v2.0.0/current"), Type: slack.ServiceType},
+	}
+	client := fetch.NewClient(&http.Client{Timeout: time.Second})
+
+	admin := func(method, path, body string) {
+		req, rErr := http.NewRequest(method, srv.URL+AdminPrefix+path, bytes.NewBufferString(body))
+		require.NoError(t, rErr)
+		resp, dErr := http.DefaultClient.Do(req)
+		require.NoError(t, dErr)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	o := sites.GetOverview(client)
+	require.Empty(t, o.Errors)
+	require.Equal(t, "none", o.OverallStatus)
+	require.Len(t, o.List["none"], 3)
+
+	admin(http.MethodPost, "scenarios/outage", "")
+
+	o = sites.GetOverview(client)
+	require.Empty(t, o.Errors)
+	require.Equal(t, "major", o.OverallStatus)
+	require.Equal(t, "CircleCI", o.List["major"][0].Name())
+
+	admin(http.MethodPut, "services/github", `{"format":"statuspage.io","behavior":"error"}`)
+	admin(http.MethodPut, "services/slack", `{"format":"slack","behavior":"malformed"}`)
+	admin(http.MethodPut, "services/circleci", `{"format":"statuspage.io","delay":"2s"}`)
+
+	o = sites.GetOverview(client)
+	require.Len(t, o.Errors, 3)
+	require.Equal(t, "none", o.OverallStatus)
+}
+
+func TestUnit_Server_Admin(t *testing.T) {
+	srv := httptest.NewServer(New("scenarios", Scenario{}))
+	defer srv.Close()
+
+	tests := map[string]struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		"base path- replace the scenario": {
+			method:       http.MethodPut,
+			path:         "scenario",
+			body:         `{"services":{"acme":{"format":"slack","status":"minor"}}}`,
+			expectedCode: http.StatusOK,
+		},
+		"exceptional path- unsupported format": {
+			method:       http.MethodPut,
+			path:         "services/acme",
+			body:         `{"format":"carrier-pigeon"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"exceptional path- unsupported status": {
+			method:       http.MethodPut,
+			path:         "services/acme",
+			body:         `{"format":"slack","status":"on-fire"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"exceptional path- unknown scenario": {
+			method:       http.MethodPost,
+			path:         "scenarios/missing",
+			expectedCode: http.StatusBadRequest,
+		},
+		"exceptional path- scenario outside the directory": {
+			method:       http.MethodPost,
+			path:         "scenarios/..%2Fsecrets",
+			expectedCode: http.StatusBadRequest,
+		},
+		"exceptional path- unknown endpoint": {
+			method:       http.MethodGet,
+			path:         "nope",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+AdminPrefix+tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			resp, dErr := http.DefaultClient.Do(req)
+			require.NoError(t, dErr)
+			_ = resp.Body.Close()
+			require.Equal(t, tc.expectedCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package fetch
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+// Error codes
+const (
+	ErrorUnableToParseCache = "UNABLE_TO_PARSE_CACHE"
+	ErrorUnableToWriteCache = "UNABLE_TO_WRITE_CACHE"
+)
+
+// CacheRetention is how long an entry is kept without being used, e.g. after its site was removed
+const CacheRetention = 7 * 24 * time.Hour
+
+// CacheEntry is the last response a page gave along with what we need to revalidate it
+type CacheEntry struct {
+	// FetchedAt is when the page last confirmed the response is current, either by sending it or by responding 304
+	FetchedAt    time.Time       `json:"fetched_at"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	FreshUntil   time.Time       `json:"fresh_until,omitempty"`
+	UsedAt       time.Time       `json:"used_at"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// CacheStats count how every cached request was answered
+type CacheStats struct {
+	// Fresh requests were answered from the cache without going over the network
+	Fresh int `json:"fresh"`
+	// Revalidated requests were answered from the cache after the page responded 304 Not Modified
+	Revalidated int `json:"revalidated"`
+	// Downloaded requests had the page send its whole response
+	Downloaded int `json:"downloaded"`
+}
+
+// HitRate returns the share of requests answered from the cache, as a percentage
+func (s CacheStats) HitRate() float64 {
+	total := s.Fresh + s.Revalidated + s.Downloaded
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Fresh+s.Revalidated) * 100 / float64(total)
+}
+
+// String summarizes the stats for display
+func (s CacheStats) String() string {
+	return strconv.FormatFloat(s.HitRate(), 'f', 1, 64) + "% hit rate (" + strconv.Itoa(s.Fresh) + " fresh, " +
+		strconv.Itoa(s.Revalidated) + " revalidated, " + strconv.Itoa(s.Downloaded) + " downloaded)"
+}
+
+func (s CacheStats) add(o CacheStats) CacheStats {
+	return CacheStats{Fresh: s.Fresh + o.Fresh, Revalidated: s.Revalidated + o.Revalidated, Downloaded: s.Downloaded + o.Downloaded}
+}
+
+// Cache keeps the responses of the pages we request so unchanged pages are not downloaded again. It sends
+// If-None-Match and If-Modified-Since to revalidate entries, and skips the request entirely while an entry is fresh
+// according to the page's Cache-Control max-age. It is safe to share between the requests of a run.
+type Cache struct {
+	Entries map[string]CacheEntry `json:"entries"`
+	// Stats accumulate across runs
+	Stats CacheStats `json:"stats"`
+	// Run holds the stats of the current run only
+	Run CacheStats `json:"-"`
+	// Clock provides the current time; time.Now is used when not set
+	Clock func() time.Time `json:"-"`
+
+	mu sync.Mutex
+}
+
+// NewCache returns an empty cache
+func NewCache() *Cache {
+	return &Cache{Entries: map[string]CacheEntry{}}
+}
+
+// LoadCache reads the cache from disk; a missing file means an empty cache
+func LoadCache(r configuration.Reader, filename string) (*Cache, glitch.DataError) {
+	data, rErr := r.ReadFile(filename)
+	if rErr != nil {
+		return NewCache(), nil
+	}
+
+	c := NewCache()
+	uErr := json.Unmarshal(data, c)
+	if uErr != nil {
+		return NewCache(), glitch.NewDataError(uErr, ErrorUnableToParseCache, "error parsing What's Up HTTP cache")
+	}
+
+	if c.Entries == nil {
+		c.Entries = map[string]CacheEntry{}
+	}
+
+	return c, nil
+}
+
+// Save writes the cache to disk, dropping entries that have not been used for a while and adding this run's stats
+func (c *Cache) Save(w configuration.Writer, filename string) glitch.DataError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := c.now().Add(-CacheRetention)
+	for k, e := range c.Entries {
+		if e.UsedAt.Before(cutoff) {
+			delete(c.Entries, k)
+		}
+	}
+
+	c.Stats = c.Stats.add(c.Run)
+	c.Run = CacheStats{}
+
+	data, mErr := json.Marshal(c)
+	if mErr != nil {
+		return glitch.NewDataError(mErr, ErrorUnableToWriteCache, "unable to encode What's Up HTTP cache")
+	}
+
+	wErr := w.WriteFile(filename, data, 0600)
+	if wErr != nil {
+		return glitch.NewDataError(wErr, ErrorUnableToWriteCache, "unable to write What's Up HTTP cache")
+	}
+
+	return nil
+}
+
+func (c *Cache) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+
+	return c.Clock()
+}
+
+// CheckedAt returns when the page last confirmed its cached response is current
+func (c *Cache) CheckedAt(pageURL string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.Entries[pageURL]
+	return e.FetchedAt, ok
+}
+
+// fresh returns the body cached for the page if it can be used without a request, either because the page said it
+// is still fresh or because the page's interval has not elapsed yet
+func (c *Cache) fresh(pageURL string, interval time.Duration) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.Entries[pageURL]
+	now := c.now()
+	if !ok {
+		return nil, false
+	}
+
+	if !now.Before(e.FreshUntil) && !now.Before(e.FetchedAt.Add(interval)) {
+		return nil, false
+	}
+
+	e.UsedAt = now
+	c.Entries[pageURL] = e
+	c.Run.Fresh++
+
+	return e.Body, true
+}
+
+// conditional adds the validators of the cached entry to the request
+func (c *Cache) conditional(pageURL string, req *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.Entries[pageURL]
+	if !ok {
+		return
+	}
+
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// revalidated returns the cached body after the page responded 304 Not Modified, refreshing how long it stays fresh
+func (c *Cache) revalidated(pageURL string, resp *http.Response) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.Entries[pageURL]
+	if !ok {
+		return nil, false
+	}
+
+	now := c.now()
+	e.FetchedAt = now
+	e.UsedAt = now
+	e.FreshUntil = freshUntil(resp.Header, now)
+	c.Entries[pageURL] = e
+	c.Run.Revalidated++
+
+	return e.Body, true
+}
+
+// store caches a full response, unless the page asked us not to
+func (c *Cache) store(pageURL string, resp *http.Response, body []byte, interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Run.Downloaded++
+
+	directives := cacheControl(resp.Header)
+	if _, ok := directives["no-store"]; ok {
+		delete(c.Entries, pageURL)
+		return
+	}
+
+	now := c.now()
+	e := CacheEntry{
+		FetchedAt:    now,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FreshUntil:   freshUntil(resp.Header, now),
+		UsedAt:       now,
+		Body:         append(json.RawMessage(nil), body...),
+	}
+
+	if e.ETag == "" && e.LastModified == "" && !e.FreshUntil.After(now) && interval <= 0 {
+		// Nothing to revalidate with and never reused; caching it would not save a request
+		delete(c.Entries, pageURL)
+		return
+	}
+
+	c.Entries[pageURL] = e
+}
+
+// cacheControl parses the Cache-Control header into its lower cased directives
+func cacheControl(h http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return directives
+}
+
+// freshUntil returns when a response stops being fresh based on its max-age, less the time it already spent in
+// shared caches
+func freshUntil(h http.Header, now time.Time) time.Time {
+	directives := cacheControl(h)
+	if _, ok := directives["no-cache"]; ok {
+		return time.Time{}
+	}
+
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	if err != nil || maxAge <= 0 {
+		return time.Time{}
+	}
+
+	age, _ := strconv.Atoi(h.Get("Age"))
+	if age >= maxAge {
+		return time.Time{}
+	}
+
+	return now.Add(time.Duration(maxAge-age) * time.Second)
+}
@@ -0,0 +1,199 @@
+package fetch
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Client_GetJSON_Cache(t *testing.T) {
+	tests := map[string]struct {
+		headers         http.Header
+		interval        Duration
+		advance         time.Duration
+		expectedSent    http.Header
+		expectedCalls   int
+		expectedRun     CacheStats
+		expectedEntries int
+	}{
+		"base path- revalidates with the ETag": {
+			headers:         http.Header{"Etag": {`"v1"`}},
+			expectedSent:    http.Header{"If-None-Match": {`"v1"`}},
+			expectedCalls:   2,
+			expectedRun:     CacheStats{Revalidated: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- revalidates with the last modified date": {
+			headers:         http.Header{"Last-Modified": {"Mon, 04 Mar 2024 15:04:05 GMT"}},
+			expectedSent:    http.Header{"If-Modified-Since": {"Mon, 04 Mar 2024 15:04:05 GMT"}},
+			expectedCalls:   2,
+			expectedRun:     CacheStats{Revalidated: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- fresh response skips the request": {
+			headers:         http.Header{"Cache-Control": {"public, max-age=60"}, "Etag": {`"v1"`}},
+			advance:         59 * time.Second,
+			expectedCalls:   1,
+			expectedRun:     CacheStats{Fresh: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- stale response is revalidated": {
+			headers:         http.Header{"Cache-Control": {"max-age=60"}, "Age": {"30"}, "Etag": {`"v1"`}},
+			advance:         30 * time.Second,
+			expectedSent:    http.Header{"If-None-Match": {`"v1"`}},
+			expectedCalls:   2,
+			expectedRun:     CacheStats{Revalidated: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- no-cache always revalidates": {
+			headers:         http.Header{"Cache-Control": {"no-cache, max-age=60"}, "Etag": {`"v1"`}},
+			expectedSent:    http.Header{"If-None-Match": {`"v1"`}},
+			expectedCalls:   2,
+			expectedRun:     CacheStats{Revalidated: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- no-store is not cached": {
+			headers:       http.Header{"Cache-Control": {"no-store"}, "Etag": {`"v1"`}},
+			expectedSent:  http.Header{},
+			expectedCalls: 2,
+			expectedRun:   CacheStats{Downloaded: 2},
+		},
+		"base path- interval not elapsed skips the request": {
+			interval:        Duration(5 * time.Minute),
+			advance:         4 * time.Minute,
+			expectedCalls:   1,
+			expectedRun:     CacheStats{Fresh: 1, Downloaded: 1},
+			expectedEntries: 1,
+		},
+		"base path- interval elapsed requests the page again": {
+			interval:        Duration(5 * time.Minute),
+			advance:         5 * time.Minute,
+			expectedSent:    http.Header{},
+			expectedCalls:   2,
+			expectedRun:     CacheStats{Downloaded: 2},
+			expectedEntries: 1,
+		},
+		"base path- nothing to revalidate with is not cached": {
+			expectedSent:  http.Header{},
+			expectedCalls: 2,
+			expectedRun:   CacheStats{Downloaded: 2},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			var sent http.Header
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				sent = http.Header{}
+				for _, h := range []string{"If-None-Match", "If-Modified-Since"} {
+					if v := r.Header.Get(h); v != "" {
+						sent.Set(h, v)
+					}
+				}
+
+				for k, v := range tc.headers {
+					w.Header()[k] = v
+				}
+
+				if len(sent) > 0 {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+			}))
+			defer srv.Close()
+
+			now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.UTC)
+			cache := NewCache()
+			cache.Clock = func() time.Time { return now }
+
+			c := NewClient(&http.Client{}).WithOptions(Options{Interval: tc.interval})
+			c.Cache = cache
+
+			var first, second map[string]string
+			require.NoError(t, c.GetJSON(srv.URL, &first))
+
+			now = now.Add(tc.advance)
+			require.NoError(t, c.GetJSON(srv.URL, &second))
+
+			require.Equal(t, first, second)
+			require.Equal(t, "ok", second["status"])
+			require.Equal(t, tc.expectedCalls, calls)
+			if tc.expectedSent != nil {
+				require.Equal(t, tc.expectedSent, sent)
+			}
+			require.Equal(t, tc.expectedRun, cache.Run)
+			require.Len(t, cache.Entries, tc.expectedEntries)
+
+			if tc.expectedEntries > 0 {
+				checkedAt, ok := cache.CheckedAt(srv.URL)
+				require.True(t, ok)
+				if tc.expectedCalls == 1 {
+					require.Equal(t, now.Add(-tc.advance), checkedAt)
+				} else {
+					require.Equal(t, now, checkedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestUnit_Cache_SaveAndLoad(t *testing.T) {
+	now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.UTC)
+
+	cache := NewCache()
+	cache.Clock = func() time.Time { return now }
+	cache.Stats = CacheStats{Fresh: 1, Revalidated: 2, Downloaded: 3}
+	cache.Run = CacheStats{Fresh: 3, Revalidated: 2, Downloaded: 1}
+	cache.Entries = map[string]CacheEntry{
+		"https://recent.example.com":  {ETag: `"v1"`, UsedAt: now.Add(-time.Hour), Body: []byte(`{"status":"ok"}`)},
+		"https://removed.example.com": {ETag: `"v1"`, UsedAt: now.Add(-CacheRetention - time.Hour), Body: []byte(`{}`)},
+	}
+
+	w := &memoryFile{}
+	require.NoError(t, cache.Save(w, "cache.json"))
+
+	loaded, err := LoadCache(w, "cache.json")
+	require.NoError(t, err)
+	require.Equal(t, CacheStats{Fresh: 4, Revalidated: 4, Downloaded: 4}, loaded.Stats)
+	require.Equal(t, 2.0/3*100, loaded.Stats.HitRate())
+	require.Equal(t, "66.7% hit rate (4 fresh, 4 revalidated, 4 downloaded)", loaded.Stats.String())
+	require.Len(t, loaded.Entries, 1)
+	require.Equal(t, `"v1"`, loaded.Entries["https://recent.example.com"].ETag)
+
+	missing, mErr := LoadCache(&memoryFile{}, "cache.json")
+	require.NoError(t, mErr)
+	require.Empty(t, missing.Entries)
+
+	corrupt, cErr := LoadCache(&memoryFile{data: []byte(`{`)}, "cache.json")
+	require.Error(t, cErr)
+	require.Equal(t, ErrorUnableToParseCache, cErr.Code())
+	require.Empty(t, corrupt.Entries)
+}
+
+// memoryFile is a configuration reader and writer keeping a single file in memory
+type memoryFile struct {
+	data []byte
+}
+
+func (m *memoryFile) ReadFile(_ string) ([]byte, error) {
+	if m.data == nil {
+		return nil, errors.New("file does not exist")
+	}
+
+	return m.data, nil
+}
+
+func (m *memoryFile) WriteFile(_ string, data []byte, _ fs.FileMode) error {
+	m.data = data
+	return nil
+}
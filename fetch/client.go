@@ -0,0 +1,259 @@
+// Package fetch handles making the HTTP requests for the status pages we monitor
+package fetch
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	"github.com/sprak3000/go-whatsup-client/slack"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/statuspageio"
+)
+
+// Error codes
+const (
+	ErrorUnableToParseResponse = "UNABLE_TO_PARSE_RESPONSE"
+	ErrorUnexpectedStatusCode  = "UNEXPECTED_STATUS_CODE"
+	ErrorUnsupportedClient     = "UNSUPPORTED_CLIENT"
+)
+
+// SlackURL is where Slack publishes its current status
+const SlackURL = "https://status.slack.com/api/v2.0.0/current"
+
+// DefaultTimeout is how long we wait on a status page before giving up
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxBodySize is the most we read from a status page; anything larger is not a status payload
+const DefaultMaxBodySize = 5 << 20
+
+// Retry limits; a plugin run should not spend much longer on a page than its timeout
+const (
+	// MaxRetries is the most times a request is retried, whatever the configuration asks for
+	MaxRetries = 5
+	// RetryBackoff is how long we wait before the first retry; every retry after it waits twice as long
+	RetryBackoff = 500 * time.Millisecond
+	// MaxRetryWait is the longest we wait before a retry, including when the page asks for longer with Retry-After
+	MaxRetryWait = 5 * time.Second
+)
+
+// Client implements the whatsup.StatusPageClient interface on top of a standard HTTP client, giving us control over
+// how requests are made
+type Client struct {
+	HTTPClient *http.Client
+	Options    Options
+	// Cache keeps responses between runs; requests are not cached when nil
+	Cache *Cache
+	// Logger traces every request; nothing is logged when nil
+	Logger *slog.Logger
+	// PageRead is called with every page read, whether it came from the cache or the network
+	PageRead func(pageURL string)
+	// Sleep waits before a retry; time.Sleep is used when not set
+	Sleep func(d time.Duration)
+
+	clients    *clientCache
+	configured *configuredClient
+	secrets    *secretCache
+}
+
+// NewClient returns a client making requests with the given HTTP client
+func NewClient(httpClient *http.Client) Client {
+	return Client{HTTPClient: httpClient, clients: newClientCache(), secrets: newSecretCache()}
+}
+
+// GetJSON requests the page and decodes its JSON body into v
+func (c Client) GetJSON(pageURL string, v interface{}) glitch.DataError {
+	t := trace{start: time.Now()}
+
+	err := c.getJSON(pageURL, v, &t)
+	c.logRequest(pageURL, t, err)
+
+	if c.PageRead != nil {
+		c.PageRead(pageURL)
+	}
+
+	return err
+}
+
+func (c Client) getJSON(pageURL string, v interface{}, t *trace) glitch.DataError {
+	if c.Cache != nil {
+		if body, ok := c.Cache.fresh(pageURL, time.Duration(c.Options.Interval)); ok {
+			t.cache, t.bytes = cacheFresh, len(body)
+			return decode(pageURL, body, v)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return glitch.NewDataError(err, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request "+pageURL)
+	}
+
+	aErr := c.Options.apply(req, c.secrets)
+	if aErr != nil {
+		return aErr
+	}
+
+	hc, hErr := c.httpClient()
+	if hErr != nil {
+		return hErr
+	}
+
+	if c.Cache != nil {
+		c.Cache.conditional(pageURL, req)
+	}
+
+	resp, err := c.do(hc, req, t)
+	if err != nil {
+		return glitch.NewDataError(err, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request "+pageURL)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	t.status = resp.StatusCode
+
+	if resp.StatusCode == http.StatusNotModified && c.Cache != nil {
+		if body, ok := c.Cache.revalidated(pageURL, resp); ok {
+			t.cache, t.bytes = cacheRevalidated, len(body)
+			return decode(pageURL, body, v)
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return glitch.NewDataError(nil, ErrorUnexpectedStatusCode, pageURL+" responded with "+resp.Status)
+	}
+
+	body, rErr := readBody(resp, pageURL, c.maxBodySize())
+	if rErr != nil {
+		return rErr
+	}
+	t.bytes = len(body)
+
+	vErr := validateJSON(resp, body, pageURL)
+	if vErr != nil {
+		return vErr
+	}
+
+	dErr := decode(pageURL, body, v)
+	if dErr != nil {
+		return dErr
+	}
+
+	if c.Cache != nil {
+		t.cache = cacheDownloaded
+		c.Cache.store(pageURL, resp, body, time.Duration(c.Options.Interval))
+	}
+
+	return nil
+}
+
+// do sends the request, sending it again up to Options.Retries times while it fails with a network error or a
+// response asking us to come back later
+func (c Client) do(hc *http.Client, req *http.Request, t *trace) (*http.Response, error) {
+	retries := min(c.Options.Retries, MaxRetries)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := hc.Do(req.Clone(req.Context()))
+		if attempt >= retries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait := retryWait(resp, attempt)
+		c.logRetry(req.URL.String(), attempt+1, resp, err, wait)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		t.retries++
+		c.sleep(wait)
+	}
+}
+
+// retryable reports whether a request failed in a way that may not happen again
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryWait returns how long to wait before a retry: what the page asked for with Retry-After, or a backoff doubling
+// with every attempt, never more than MaxRetryWait
+func retryWait(resp *http.Response, attempt int) time.Duration {
+	wait := RetryBackoff << attempt
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return min(wait, MaxRetryWait)
+}
+
+func (c Client) sleep(d time.Duration) {
+	if c.Sleep == nil {
+		time.Sleep(d)
+		return
+	}
+
+	c.Sleep(d)
+}
+
+func decode(pageURL string, body []byte, v interface{}) glitch.DataError {
+	err := json.Unmarshal(body, v)
+	if err != nil {
+		return glitch.NewDataError(err, ErrorUnableToParseResponse, "unable to parse the response from "+pageURL)
+	}
+
+	return nil
+}
+
+// StatuspageIoService reads the status of a statuspage.io page
+func (c Client) StatuspageIoService(serviceName, pageURL string) (whatsupstatus.Details, glitch.DataError) {
+	var resp statuspageio.Response
+
+	err := c.GetJSON(pageURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show the service under the name it was given in the configuration
+	resp.Page.Name = serviceName
+
+	return resp, nil
+}
+
+// Slack reads the status of Slack
+func (c Client) Slack() (whatsupstatus.Details, glitch.DataError) {
+	var resp slack.Response
+
+	err := c.GetJSON(SlackURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Getter is implemented by clients able to request any JSON document, letting providers the go-whatsup-client does not
+// know about read their pages
+type Getter interface {
+	GetJSON(pageURL string, v interface{}) glitch.DataError
+}
+
+// AsGetter returns the client as a Getter, or an error naming the service type it is unable to read
+func AsGetter(client interface{}, serviceType string) (Getter, glitch.DataError) {
+	g, ok := client.(Getter)
+	if !ok {
+		return nil, glitch.NewDataError(nil, ErrorUnsupportedClient, "the client is unable to read "+serviceType+" pages")
+	}
+
+	return g, nil
+}
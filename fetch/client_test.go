@@ -0,0 +1,217 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Client_GetJSON(t *testing.T) {
+	pageURL := "https://status.example.com/api/v2/status.json"
+
+	tests := map[string]struct {
+		client       Client
+		expectedCode string
+		expectedBody map[string]interface{}
+	}{
+		"base path": {
+			client:       NewStaticClient(http.StatusOK, "application/json; charset=utf-8", []byte(`{"status":"ok"}`)),
+			expectedBody: map[string]interface{}{"status": "ok"},
+		},
+		"base path- vendor JSON type with leading byte order mark and whitespace": {
+			client:       NewStaticClient(http.StatusOK, "application/vnd.status+json", []byte("\xEF\xBB\xBF\n  {\"status\":\"ok\"}")),
+			expectedBody: map[string]interface{}{"status": "ok"},
+		},
+		"base path- JSON served as plain text": {
+			client:       NewStaticClient(http.StatusOK, "text/plain", []byte(`{"status":"ok"}`)),
+			expectedBody: map[string]interface{}{"status": "ok"},
+		},
+		"base path- larger body allowed by the options": {
+			client:       NewStaticClient(http.StatusOK, "application/json", []byte(`{"status":"ok"}`)).WithOptions(Options{MaxBodySize: 15}),
+			expectedBody: map[string]interface{}{"status": "ok"},
+		},
+		"exceptional path- HTML content type": {
+			client:       NewStaticClient(http.StatusOK, "text/html; charset=utf-8", []byte(`{"status":"ok"}`)),
+			expectedCode: ErrorHTMLReceived,
+		},
+		"exceptional path- HTML body served as JSON": {
+			client:       NewStaticClient(http.StatusOK, "application/json", []byte("\n<!DOCTYPE html><html></html>")),
+			expectedCode: ErrorHTMLReceived,
+		},
+		"exceptional path- unexpected content type": {
+			client:       NewStaticClient(http.StatusOK, "image/png", []byte(`{"status":"ok"}`)),
+			expectedCode: ErrorUnexpectedContentType,
+		},
+		"exceptional path- body is not an object or array": {
+			client:       NewStaticClient(http.StatusOK, "application/json", []byte(`"ok"`)),
+			expectedCode: ErrorUnexpectedContentType,
+		},
+		"exceptional path- empty body": {
+			client:       NewStaticClient(http.StatusOK, "application/json", nil),
+			expectedCode: ErrorUnexpectedContentType,
+		},
+		"exceptional path- content length over the limit": {
+			client:       NewStaticClient(http.StatusOK, "application/json", []byte(`{"status":"ok"}`)).WithOptions(Options{MaxBodySize: 14}),
+			expectedCode: ErrorResponseTooLarge,
+		},
+		"exceptional path- unknown content length over the limit": {
+			client: NewClient(&http.Client{
+				Transport: TransportFunc(func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode:    http.StatusOK,
+						Header:        http.Header{"Content-Type": []string{"application/json"}},
+						Body:          io.NopCloser(strings.NewReader(`[` + strings.Repeat(`1,`, DefaultMaxBodySize) + `1]`)),
+						ContentLength: -1,
+						Request:       req,
+					}, nil
+				}),
+			}),
+			expectedCode: ErrorResponseTooLarge,
+		},
+		"exceptional path- unexpected status code": {
+			client:       NewStaticClient(http.StatusNotFound, "text/html", []byte(`<html></html>`)),
+			expectedCode: ErrorUnexpectedStatusCode,
+		},
+		"exceptional path- malformed JSON": {
+			client:       NewStaticClient(http.StatusOK, "application/json", bytes.Repeat([]byte(`{`), 3)),
+			expectedCode: ErrorUnableToParseResponse,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body map[string]interface{}
+
+			err := tc.client.GetJSON(pageURL, &body)
+			if tc.expectedCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedCode, err.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedBody, body)
+		})
+	}
+}
+
+func TestUnit_Client_GetJSON_Retries(t *testing.T) {
+	pageURL := "https://status.example.com/api/v2/status.json"
+
+	tests := map[string]struct {
+		retries          int
+		responses        []int
+		retryAfter       string
+		expectedAttempts int
+		expectedWaits    []time.Duration
+		expectedCode     string
+	}{
+		"base path- retried until the page answers": {
+			retries:          3,
+			responses:        []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedAttempts: 3,
+			expectedWaits:    []time.Duration{RetryBackoff, 2 * RetryBackoff},
+		},
+		"base path- Retry-After is honored up to the longest wait": {
+			retries:          2,
+			responses:        []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:       "120",
+			expectedAttempts: 3,
+			expectedWaits:    []time.Duration{MaxRetryWait, MaxRetryWait},
+		},
+		"base path- no retries when not set": {
+			responses:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedAttempts: 1,
+			expectedCode:     ErrorUnexpectedStatusCode,
+		},
+		"exceptional path- retries run out": {
+			retries:          1,
+			responses:        []int{http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusOK},
+			expectedAttempts: 2,
+			expectedWaits:    []time.Duration{RetryBackoff},
+			expectedCode:     ErrorUnexpectedStatusCode,
+		},
+		"exceptional path- errors that would happen again are not retried": {
+			retries:          3,
+			responses:        []int{http.StatusNotFound, http.StatusOK},
+			expectedAttempts: 1,
+			expectedCode:     ErrorUnexpectedStatusCode,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			var waits []time.Duration
+			var buf bytes.Buffer
+
+			c := NewClient(&http.Client{
+				Transport: TransportFunc(func(req *http.Request) (*http.Response, error) {
+					resp, err := NewStaticClient(tc.responses[attempts], "application/json", []byte(`{}`)).HTTPClient.Transport.RoundTrip(req)
+					if tc.retryAfter != "" {
+						resp.Header.Set("Retry-After", tc.retryAfter)
+					}
+					attempts++
+					return resp, err
+				}),
+			}).WithOptions(Options{Retries: tc.retries})
+			c.Sleep = func(d time.Duration) { waits = append(waits, d) }
+			c.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			var body map[string]interface{}
+			err := c.GetJSON(pageURL, &body)
+			if tc.expectedCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedCode, err.Code())
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expectedAttempts, attempts)
+			require.Equal(t, tc.expectedWaits, waits)
+
+			// Every retry is logged, then the request with how many retries it took
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, len(tc.expectedWaits)+1)
+			for i, line := range lines[:len(lines)-1] {
+				var entry map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+				require.Equal(t, "retrying request", entry["msg"])
+				require.Equal(t, float64(i+1), entry["attempt"])
+			}
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &entry))
+			if len(tc.expectedWaits) > 0 {
+				require.Equal(t, float64(len(tc.expectedWaits)), entry["retries"])
+			} else {
+				require.NotContains(t, entry, "retries")
+			}
+		})
+	}
+}
+
+func TestUnit_Client_GetJSON_Retries_NetworkError(t *testing.T) {
+	attempts := 0
+
+	c := NewClient(&http.Client{
+		Transport: TransportFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return NewStaticClient(http.StatusOK, "application/json", []byte(`{}`)).HTTPClient.Transport.RoundTrip(req)
+		}),
+	}).WithOptions(Options{Retries: 1})
+	c.Sleep = func(time.Duration) {}
+
+	var body map[string]interface{}
+	require.NoError(t, c.GetJSON("https://status.example.com/api/v2/status.json", &body))
+	require.Equal(t, 2, attempts)
+}
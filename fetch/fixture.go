@@ -0,0 +1,142 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+var unsafeFixtureChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// FixtureName returns the file name we record the response for a URL under, e.g.
+// "status.circleci.com_api_v2_status.json.http"
+func FixtureName(u *url.URL) string {
+	name := u.Host + u.Path
+	if u.RawQuery != "" {
+		name += "_" + u.RawQuery
+	}
+
+	return strings.Trim(unsafeFixtureChars.ReplaceAllString(name, "_"), "_") + ".http"
+}
+
+// Headers the RecordingTransport adds to every response it saves so a fixture keeps where and when it was captured
+const (
+	RecordedFromHeader = "X-Recorded-From"
+	RecordedAtHeader   = "X-Recorded-At"
+)
+
+// RecordingTransport saves every raw HTTP response it receives into a fixture directory for later replay
+type RecordingTransport struct {
+	Dir       string
+	Writer    configuration.Writer
+	Transport http.RoundTripper
+	// Clock provides the time recorded in RecordedAtHeader; time.Now is used when not set
+	Clock func() time.Time
+}
+
+// RoundTrip makes the request and records the response
+func (rt RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if rt.Clock != nil {
+		now = rt.Clock
+	}
+	resp.Header.Set(RecordedFromHeader, req.URL.Redacted())
+	resp.Header.Set(RecordedAtHeader, now().UTC().Format(time.RFC3339))
+
+	// DumpResponse reads the body and replaces it with a copy so the caller can still read it
+	dump, dErr := httputil.DumpResponse(resp, true)
+	if dErr != nil {
+		_ = resp.Body.Close()
+		return nil, dErr
+	}
+
+	wErr := rt.Writer.WriteFile(filepath.Join(rt.Dir, FixtureName(req.URL)), dump, 0644)
+	if wErr != nil {
+		_ = resp.Body.Close()
+		return nil, wErr
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport serves responses previously saved by the RecordingTransport instead of making requests
+type ReplayTransport struct {
+	Dir    string
+	Reader configuration.Reader
+}
+
+// RoundTrip returns the recorded response for the request URL
+func (rt ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := rt.Reader
+	if r == nil {
+		r = configuration.FileReader{}
+	}
+
+	data, err := r.ReadFile(filepath.Join(rt.Dir, FixtureName(req.URL)))
+	if err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+}
+
+// NewReplayClient returns a client serving every request from the fixtures in the directory
+func NewReplayClient(dir string) Client {
+	return NewClient(&http.Client{Transport: ReplayTransport{Dir: dir}})
+}
+
+// NewRecordingClient returns a client saving every response into the directory, creating it if needed
+func NewRecordingClient(dir string) (Client, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return Client{}, err
+	}
+
+	return NewClient(&http.Client{
+		Timeout: DefaultTimeout,
+		Transport: RecordingTransport{
+			Dir:       dir,
+			Writer:    configuration.FileWriter{},
+			Transport: http.DefaultTransport,
+		},
+	}), nil
+}
+
+// TransportFunc adapts a function into an http.RoundTripper
+type TransportFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls the function
+func (f TransportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// NewStaticClient returns a client answering every request with the given status code, content type, and body
+func NewStaticClient(statusCode int, contentType string, body []byte) Client {
+	return NewClient(&http.Client{
+		Transport: TransportFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode:    statusCode,
+				Status:        http.StatusText(statusCode),
+				Header:        http.Header{"Content-Type": []string{contentType}},
+				Body:          io.NopCloser(bytes.NewReader(body)),
+				ContentLength: int64(len(body)),
+				Request:       req,
+			}, nil
+		}),
+	})
+}
@@ -0,0 +1,97 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+func TestUnit_FixtureName(t *testing.T) {
+	tests := map[string]struct {
+		rawURL       string
+		expectedName string
+	}{
+		"base path": {
+			rawURL:       "https://status.circleci.com/api/v2/status.json",
+			expectedName: "status.circleci.com_api_v2_status.json.http",
+		},
+		"base path- query string and port": {
+			rawURL:       "http://127.0.0.1:8080/api/status-page/main?heartbeat=1",
+			expectedName: "127.0.0.1_8080_api_status-page_main_heartbeat_1.http",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tc.rawURL)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedName, FixtureName(u))
+		})
+	}
+}
+
+func TestUnit_RecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"page":{"name":"Recorded"},"status":{"indicator":"minor","description":"Minor Service Outage"}}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	recorder, err := NewRecordingClient(dir)
+	require.NoError(t, err)
+
+	recorded, rErr := recorder.StatuspageIoService("Recorded", srv.URL+"/api/v2/status.json")
+	require.NoError(t, rErr)
+	require.Equal(t, "minor", recorded.Indicator())
+
+	// The server is gone; replay has to come from the fixture
+	srv.Close()
+
+	replayed, pErr := NewReplayClient(dir).StatuspageIoService("Recorded", srv.URL+"/api/v2/status.json")
+	require.NoError(t, pErr)
+	require.Equal(t, recorded, replayed)
+}
+
+func TestUnit_RecordingTransport_Provenance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+	client := &http.Client{Transport: RecordingTransport{
+		Dir:       dir,
+		Writer:    configuration.FileWriter{},
+		Transport: http.DefaultTransport,
+		Clock:     func() time.Time { return now },
+	}}
+
+	u, err := url.Parse(srv.URL + "/api/v2/status.json?page=1")
+	require.NoError(t, err)
+	u.User = url.UserPassword("user", "s3cret")
+
+	resp, err := client.Get(u.String())
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, FixtureName(u)))
+	require.NoError(t, err)
+
+	recorded, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://user:xxxxx@"+u.Host+"/api/v2/status.json?page=1", recorded.Header.Get(RecordedFromHeader))
+	require.Equal(t, "2024-03-04T20:04:05Z", recorded.Header.Get(RecordedAtHeader))
+	require.NotContains(t, string(data), "s3cret")
+}
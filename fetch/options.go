@@ -0,0 +1,178 @@
+package fetch
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// Options tailor the requests made for all sites or, when set on a site, for a single site
+type Options struct {
+	// MaxBodySize is the most bytes we read from a response before giving up on it
+	MaxBodySize int64 `json:"max_body_size,omitempty"`
+	// Headers are added to every request
+	Headers Headers `json:"headers,omitempty"`
+	// Auth authenticates every request
+	Auth *Auth `json:"auth,omitempty"`
+	// Proxy is the HTTP(S) proxy requests go through; the HTTPS_PROXY and HTTP_PROXY environment variables are used
+	// when not set
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy lists the hosts reached directly, in the same format as the NO_PROXY environment variable
+	NoProxy string `json:"no_proxy,omitempty"`
+	// TLS holds the certificate settings
+	TLS TLSOptions `json:"tls,omitempty"`
+	// Interval is how often the page is requested; in between, the cached response is used. Every run requests the
+	// page when not set.
+	Interval Duration `json:"interval,omitempty"`
+	// Retries is how many more times a request is sent after a network error or a 429, 502, 503, or 504 response, up
+	// to MaxRetries; failures are not retried when not set
+	Retries int `json:"retries,omitempty"`
+}
+
+// Duration is a time.Duration read from and written as a string such as "5m"
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration from a string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	if s == "" {
+		*d = 0
+		return nil
+	}
+
+	parsed, pErr := time.ParseDuration(s)
+	if pErr != nil {
+		return pErr
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d == 0 {
+		return json.Marshal("")
+	}
+
+	return json.Marshal(time.Duration(d).String())
+}
+
+// merge returns a copy of the options with every value set in the override replacing its own
+func (o Options) merge(override Options) Options {
+	if override.MaxBodySize > 0 {
+		o.MaxBodySize = override.MaxBodySize
+	}
+
+	if len(override.Headers) > 0 {
+		headers := make(Headers, len(o.Headers)+len(override.Headers))
+		for k, v := range o.Headers {
+			headers[k] = v
+		}
+		for k, v := range override.Headers {
+			headers[k] = v
+		}
+		o.Headers = headers
+	}
+
+	if override.Auth != nil {
+		o.Auth = override.Auth
+	}
+
+	if override.Proxy != "" {
+		o.Proxy = override.Proxy
+	}
+
+	if override.NoProxy != "" {
+		o.NoProxy = override.NoProxy
+	}
+
+	o.TLS = o.TLS.merge(override.TLS)
+
+	if override.Interval > 0 {
+		o.Interval = override.Interval
+	}
+
+	if override.Retries > 0 {
+		o.Retries = override.Retries
+	}
+
+	return o
+}
+
+// WithOptions returns a copy of the client applying the options on top of its own. The HTTP client for its proxy and
+// TLS settings is built once and shared with every other copy using the same settings.
+func (c Client) WithOptions(o Options) Client {
+	c.Options = c.Options.merge(o)
+
+	c.configured = nil
+	if c.Options.hasTransportSettings() {
+		configured := c.clients.get(c.HTTPClient, c.Options)
+		c.configured = &configured
+	}
+
+	return c
+}
+
+// WithoutCredentials returns a copy of the client dropping the headers and authentication set so far, for requests
+// to sites that should not receive them
+func (c Client) WithoutCredentials() Client {
+	c.Options.Headers = nil
+	c.Options.Auth = nil
+
+	return c
+}
+
+func (c Client) maxBodySize() int64 {
+	if c.Options.MaxBodySize > 0 {
+		return c.Options.MaxBodySize
+	}
+
+	return DefaultMaxBodySize
+}
+
+// apply adds the headers and authentication to the request, resolving their secrets through the cache
+func (o Options) apply(req *http.Request, secrets *secretCache) glitch.DataError {
+	for name, h := range o.Headers {
+		if h.Secret.isZero() {
+			req.Header.Set(name, h.Value)
+			continue
+		}
+
+		v, err := secrets.resolve(h.Secret, "header "+name)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, v)
+	}
+
+	if o.Auth == nil {
+		return nil
+	}
+
+	switch o.Auth.Type {
+	case AuthBearer:
+		token, err := secrets.resolve(o.Auth.Token, "the bearer token")
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case AuthBasic:
+		password, err := secrets.resolve(o.Auth.Password, "the basic auth password")
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(o.Auth.Username, password)
+	default:
+		return glitch.NewDataError(nil, ErrorUnsupportedAuthType, "unsupported auth type "+o.Auth.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package fetch
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+// ErrorRemoteConfigurationUnavailable is returned when the remote configuration cannot be fetched and no copy of it
+// has been kept
+const ErrorRemoteConfigurationUnavailable = "REMOTE_CONFIGURATION_UNAVAILABLE"
+
+// ErrorInsecureRemoteConfiguration is returned when the remote configuration is not served over HTTPS
+const ErrorInsecureRemoteConfiguration = "INSECURE_REMOTE_CONFIGURATION"
+
+// Configuration keys the remote reader works with
+const (
+	configKeyRemote  = "remote"
+	configKeyInclude = "include"
+)
+
+// Remote points the configuration file at a configuration published over HTTP; the options set the headers,
+// credentials, and refresh interval used to fetch it
+type Remote struct {
+	URL string `json:"url"`
+	Options
+}
+
+// RemoteCopy is the last remote configuration fetched, used until the refresh interval elapses and whenever the remote
+// is unreachable
+type RemoteCopy struct {
+	URL       string          `json:"url"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// RemoteReader implements configuration.Reader for configuration files with a remote entry. The remote configuration
+// is included ahead of everything else in the file, so local entries override it. Any other file is read with Local.
+type RemoteReader struct {
+	Local  configuration.Reader
+	Writer configuration.Writer
+	Client Client
+	// CopyFilename is where the last remote configuration fetched is kept
+	CopyFilename string
+	// Clock provides the current time; time.Now is used when not set
+	Clock func() time.Time
+
+	mu     sync.Mutex
+	remote *Remote
+	copy   *RemoteCopy
+	// fallbackErr holds why the remote could not be fetched when its copy was used instead
+	fallbackErr glitch.DataError
+}
+
+// ReadFile reads the configuration file, replacing its remote entry with an include of the remote URL; reading the
+// remote URL returns the remote configuration
+func (r *RemoteReader) ReadFile(filename string) ([]byte, error) {
+	r.mu.Lock()
+	remote := r.remote
+	r.mu.Unlock()
+
+	if remote != nil && filename == remote.URL {
+		return r.readRemote(*remote)
+	}
+
+	data, err := r.Local.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.rewrite(data), nil
+}
+
+// RemoteURL returns the URL of the remote configuration once a file with a remote entry has been read
+func (r *RemoteReader) RemoteURL() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.remote == nil {
+		return ""
+	}
+
+	return r.remote.URL
+}
+
+// rewrite turns the remote entry into the first include; files we cannot make sense of are returned as is for the
+// configuration parser to report on
+func (r *RemoteReader) rewrite(data []byte) []byte {
+	var doc map[string]json.RawMessage
+	if json.Unmarshal(data, &doc) != nil {
+		return data
+	}
+
+	raw, ok := doc[configKeyRemote]
+	if !ok {
+		return data
+	}
+
+	var remote Remote
+	if json.Unmarshal(raw, &remote) != nil || remote.URL == "" {
+		return data
+	}
+
+	var includes []interface{}
+	if existing, ok := doc[configKeyInclude]; ok {
+		var include interface{}
+		if json.Unmarshal(existing, &include) != nil {
+			return data
+		}
+		switch v := include.(type) {
+		case []interface{}:
+			includes = v
+		case nil:
+		default:
+			includes = []interface{}{v}
+		}
+	}
+
+	include, mErr := json.Marshal(append([]interface{}{remote.URL}, includes...))
+	if mErr != nil {
+		return data
+	}
+	doc[configKeyInclude] = include
+	delete(doc, configKeyRemote)
+
+	rewritten, mErr := json.Marshal(doc)
+	if mErr != nil {
+		return data
+	}
+
+	r.mu.Lock()
+	r.remote = &remote
+	r.mu.Unlock()
+
+	return rewritten
+}
+
+// readRemote returns the remote configuration, fetching it once the refresh interval has elapsed and falling back to
+// the copy kept from an earlier run when the remote is unreachable
+func (r *RemoteReader) readRemote(remote Remote) ([]byte, error) {
+	// Anyone able to tamper with the configuration decides which sites are read and how
+	if !strings.HasPrefix(remote.URL, "https://") {
+		return nil, glitch.NewDataError(nil, ErrorInsecureRemoteConfiguration, "the remote configuration "+remote.URL+" must be served over https")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.copy == nil {
+		r.copy = r.loadCopy(remote.URL)
+	}
+
+	now := r.now()
+	if r.copy != nil && now.Sub(r.copy.FetchedAt) < time.Duration(remote.Interval) {
+		return r.copy.Body, nil
+	}
+
+	var body json.RawMessage
+	err := r.Client.WithOptions(remote.Options).GetJSON(remote.URL, &body)
+	if err != nil {
+		if r.copy == nil {
+			return nil, glitch.NewDataError(err, ErrorRemoteConfigurationUnavailable, "unable to fetch the remote configuration "+remote.URL)
+		}
+
+		r.fallbackErr = err
+		return r.copy.Body, nil
+	}
+
+	r.copy = &RemoteCopy{URL: remote.URL, FetchedAt: now, Body: body}
+	if r.Writer != nil && r.CopyFilename != "" {
+		// A copy we cannot save only costs us the fallback
+		if data, mErr := json.Marshal(r.copy); mErr == nil {
+			_ = r.Writer.WriteFile(r.CopyFilename, data, 0600)
+		}
+	}
+
+	return body, nil
+}
+
+// loadCopy returns the copy of the remote configuration kept from an earlier run, if it is for the same URL
+func (r *RemoteReader) loadCopy(url string) *RemoteCopy {
+	if r.CopyFilename == "" {
+		return nil
+	}
+
+	data, err := r.Local.ReadFile(r.CopyFilename)
+	if err != nil {
+		return nil
+	}
+
+	var c RemoteCopy
+	if json.Unmarshal(data, &c) != nil || c.URL != url || len(c.Body) == 0 {
+		return nil
+	}
+
+	return &c
+}
+
+func (r *RemoteReader) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+
+	return r.Clock()
+}
+
+// RemoteFallback describes the copy of the remote configuration used in place of the remote
+type RemoteFallback struct {
+	URL       string
+	FetchedAt time.Time
+	// Err is why the remote could not be fetched
+	Err glitch.DataError
+}
+
+// Fallback reports whether the copy of the remote configuration was used because the remote could not be fetched
+func (r *RemoteReader) Fallback() (RemoteFallback, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fallbackErr == nil || r.copy == nil {
+		return RemoteFallback{}, false
+	}
+
+	return RemoteFallback{URL: r.copy.URL, FetchedAt: r.copy.FetchedAt, Err: r.fallbackErr}, true
+}
+
+// IsRemote reports whether a configuration include names a URL rather than a file
+func IsRemote(include string) bool {
+	return strings.HasPrefix(include, "https://") || strings.HasPrefix(include, "http://")
+}
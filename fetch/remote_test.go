@@ -0,0 +1,176 @@
+package fetch
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_RemoteReader_ReadFile(t *testing.T) {
+	t.Setenv("WHATSUP_TEST_REMOTE_TOKEN", "s3cret")
+
+	var requests int
+	up := true
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Slack":{"url":"https://status.slack.com","type":"slack"}}`))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	files := memoryFiles{
+		"config.json": []byte(`{"remote":{"url":"` + srv.URL + `","auth":{"type":"bearer","token":{"env":"WHATSUP_TEST_REMOTE_TOKEN"}},"interval":"1h"},"include":"mine.json"}`),
+	}
+	newReader := func() *RemoteReader {
+		return &RemoteReader{
+			Local:        files,
+			Writer:       files,
+			Client:       NewClient(srv.Client()),
+			CopyFilename: "remote.json",
+			Clock:        func() time.Time { return now },
+		}
+	}
+
+	// The remote entry becomes the first include
+	r := newReader()
+	data, err := r.ReadFile("config.json")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"include":["`+srv.URL+`","mine.json"]}`, string(data))
+
+	// Reading the remote URL fetches it and keeps a copy
+	body, err := r.ReadFile(srv.URL)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Slack":{"url":"https://status.slack.com","type":"slack"}}`, string(body))
+	require.Equal(t, 1, requests)
+	require.Contains(t, files, "remote.json")
+	_, fallback := r.Fallback()
+	require.False(t, fallback)
+
+	// Until the interval elapses, the copy is used without fetching the remote
+	now = now.Add(30 * time.Minute)
+	r = newReader()
+	_, err = r.ReadFile("config.json")
+	require.NoError(t, err)
+	body, err = r.ReadFile(srv.URL)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Slack":{"url":"https://status.slack.com","type":"slack"}}`, string(body))
+	require.Equal(t, 1, requests)
+
+	// Once it elapses, an unreachable remote falls back to the copy
+	now = now.Add(time.Hour)
+	up = false
+	r = newReader()
+	_, err = r.ReadFile("config.json")
+	require.NoError(t, err)
+	body, err = r.ReadFile(srv.URL)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Slack":{"url":"https://status.slack.com","type":"slack"}}`, string(body))
+	require.Equal(t, 2, requests)
+	f, fallback := r.Fallback()
+	require.True(t, fallback)
+	require.Equal(t, srv.URL, f.URL)
+	require.Equal(t, time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), f.FetchedAt)
+	require.Equal(t, ErrorUnexpectedStatusCode, f.Err.Code())
+
+	// Without a copy, the remote failing is an error
+	delete(files, "remote.json")
+	r = newReader()
+	_, err = r.ReadFile("config.json")
+	require.NoError(t, err)
+	_, err = r.ReadFile(srv.URL)
+	require.Error(t, err)
+	var dErr interface{ Code() string }
+	require.True(t, errors.As(err, &dErr))
+	require.Equal(t, ErrorRemoteConfigurationUnavailable, dErr.Code())
+}
+
+func TestUnit_RemoteReader_ReadFile_Insecure(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	r := &RemoteReader{
+		Local:  memoryFiles{"config.json": []byte(`{"remote":{"url":"` + srv.URL + `"}}`)},
+		Client: NewClient(srv.Client()),
+	}
+	_, err := r.ReadFile("config.json")
+	require.NoError(t, err)
+
+	_, err = r.ReadFile(srv.URL)
+	require.Error(t, err)
+	var dErr interface{ Code() string }
+	require.True(t, errors.As(err, &dErr))
+	require.Equal(t, ErrorInsecureRemoteConfiguration, dErr.Code())
+	require.Zero(t, requests)
+}
+
+func TestUnit_RemoteReader_ReadFile_Local(t *testing.T) {
+	tests := map[string]struct {
+		data     string
+		expected string
+	}{
+		"base path- no remote entry": {
+			data:     `{"Slack":{"type":"slack"}}`,
+			expected: `{"Slack":{"type":"slack"}}`,
+		},
+		"base path- remote entry with no URL is left for the configuration parser": {
+			data:     `{"remote":{}}`,
+			expected: `{"remote":{}}`,
+		},
+		"base path- remote is included ahead of a single include": {
+			data:     `{"remote":{"url":"https://example.com/team.json"},"include":"*.json"}`,
+			expected: `{"include":["https://example.com/team.json","*.json"]}`,
+		},
+		"exceptional path- invalid file is left for the configuration parser": {
+			data:     `{`,
+			expected: `{`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &RemoteReader{Local: memoryFiles{"config.json": []byte(tc.data)}}
+			data, err := r.ReadFile("config.json")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(data))
+		})
+	}
+}
+
+func TestUnit_IsRemote(t *testing.T) {
+	require.True(t, IsRemote("https://example.com/whats-up.json"))
+	require.True(t, IsRemote("http://intranet/whats-up.json"))
+	require.False(t, IsRemote("team/whats-up.json"))
+	require.False(t, IsRemote("/etc/whats-up.json"))
+}
+
+// memoryFiles is a configuration reader and writer keeping files in memory
+type memoryFiles map[string][]byte
+
+func (m memoryFiles) ReadFile(filename string) ([]byte, error) {
+	data, ok := m[filename]
+	if !ok {
+		return nil, errors.New("file does not exist")
+	}
+
+	return data, nil
+}
+
+func (m memoryFiles) WriteFile(filename string, data []byte, _ fs.FileMode) error {
+	m[filename] = data
+	return nil
+}
@@ -0,0 +1,200 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// Error codes
+const (
+	ErrorUnableToResolveSecret = "UNABLE_TO_RESOLVE_SECRET"
+	ErrorUnsupportedAuthType   = "UNSUPPORTED_AUTH_TYPE"
+)
+
+// Auth types
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+)
+
+// Secret references a value kept outside the configuration: an environment variable, a file, or the output of a
+// command such as `pass show status/token`. Only one source is used, checked in that order. The value itself is never
+// stored or included in errors.
+type Secret struct {
+	Env     string `json:"env,omitempty"`
+	File    string `json:"file,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+func (s Secret) isZero() bool {
+	return s.Env == "" && s.File == "" && s.Command == ""
+}
+
+// Resolve reads the secret from its source, trimming surrounding whitespace
+func (s Secret) Resolve() (string, glitch.DataError) {
+	switch {
+	case s.Env != "":
+		v, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", glitch.NewDataError(nil, ErrorUnableToResolveSecret, "the environment variable "+s.Env+" is not set")
+		}
+		return strings.TrimSpace(v), nil
+	case s.File != "":
+		data, err := os.ReadFile(expandHome(s.File))
+		if err != nil {
+			return "", glitch.NewDataError(err, ErrorUnableToResolveSecret, "unable to read the secret file "+s.File)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case s.Command != "":
+		// Output only captures stdout; stderr is dropped so a failing command cannot leak into our errors
+		out, err := exec.Command("/bin/sh", "-c", s.Command).Output()
+		if err != nil {
+			return "", glitch.NewDataError(err, ErrorUnableToResolveSecret, "the secret command failed")
+		}
+		return strings.TrimSpace(string(out)), nil
+	default:
+		return "", glitch.NewDataError(nil, ErrorUnableToResolveSecret, "the secret has no env, file, or command source")
+	}
+}
+
+// expandHome replaces a leading ~ with the home directory of the current user
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Header is a request header value, either written inline for values that are not sensitive, e.g. Accept, or read
+// from a secret
+type Header struct {
+	Value  string
+	Secret Secret
+}
+
+// UnmarshalJSON accepts either a plain string or a secret reference
+func (h *Header) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*h = Header{}
+		return json.Unmarshal(data, &h.Value)
+	}
+
+	*h = Header{}
+	err := json.Unmarshal(data, &h.Secret)
+	if err != nil {
+		return err
+	}
+
+	if h.Secret.isZero() {
+		return errors.New("a header must be a string or a secret with an env, file, or command source")
+	}
+
+	return nil
+}
+
+// credentialHeaders are the headers carrying credentials; their values must be read from a secret
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// Headers maps header names to their values
+type Headers map[string]Header
+
+// UnmarshalJSON reads the headers, refusing credentials written inline
+func (hs *Headers) UnmarshalJSON(data []byte) error {
+	var headers map[string]Header
+	err := json.Unmarshal(data, &headers)
+	if err != nil {
+		return err
+	}
+
+	for name, h := range headers {
+		if h.Secret.isZero() && credentialHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("the %s header holds credentials; read it from a secret with an env, file, or command source", name)
+		}
+	}
+
+	*hs = headers
+
+	return nil
+}
+
+// MarshalJSON writes the header the way it was configured
+func (h Header) MarshalJSON() ([]byte, error) {
+	if h.Secret.isZero() {
+		return json.Marshal(h.Value)
+	}
+
+	return json.Marshal(h.Secret)
+}
+
+// Auth authenticates requests with a bearer token or basic auth
+type Auth struct {
+	// Type is bearer or basic
+	Type string `json:"type"`
+	// Token is the bearer token
+	Token Secret `json:"token,omitempty"`
+	// Username and Password are the basic auth credentials
+	Username string `json:"username,omitempty"`
+	Password Secret `json:"password,omitempty"`
+}
+
+// resolvedSecret is the value of a secret, or why it could not be read
+type resolvedSecret struct {
+	value string
+	err   glitch.DataError
+}
+
+// secretCache keeps every secret resolved during a run so each file is read and each command run once, however many
+// requests use it; it is shared by the copies of a client
+type secretCache struct {
+	mu      sync.Mutex
+	secrets map[Secret]resolvedSecret
+}
+
+func newSecretCache() *secretCache {
+	return &secretCache{secrets: map[Secret]resolvedSecret{}}
+}
+
+// resolve reads the secret the first time it is seen, wrapping a failure with what it was for and keeping the code.
+// Clients without a cache read it every time.
+func (sc *secretCache) resolve(s Secret, purpose string) (string, glitch.DataError) {
+	var resolved resolvedSecret
+
+	if sc == nil {
+		resolved.value, resolved.err = s.Resolve()
+	} else {
+		sc.mu.Lock()
+		var ok bool
+		resolved, ok = sc.secrets[s]
+		if !ok {
+			resolved.value, resolved.err = s.Resolve()
+			sc.secrets[s] = resolved
+		}
+		sc.mu.Unlock()
+	}
+
+	if resolved.err != nil {
+		return "", glitch.NewDataError(resolved.err, ErrorUnableToResolveSecret, "unable to resolve the secret for "+purpose)
+	}
+
+	return resolved.value, nil
+}
@@ -0,0 +1,89 @@
+package fetch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// How a request was answered by the cache
+const (
+	cacheFresh       = "fresh"
+	cacheRevalidated = "revalidated"
+	cacheDownloaded  = "downloaded"
+)
+
+// trace collects what happened during a request for the debug log
+type trace struct {
+	start   time.Time
+	status  int
+	bytes   int
+	cache   string
+	retries int
+}
+
+// logRequest writes the trace of a request to the debug log. Only the URL, without any credentials, and what the page
+// sent back are logged; the headers we send can hold secrets and never are.
+func (c Client) logRequest(pageURL string, t trace, err glitch.DataError) {
+	if c.Logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("url", redactURL(pageURL)),
+		slog.Duration("latency", time.Since(t.start)),
+		slog.Int("bytes", t.bytes),
+	}
+	if t.status != 0 {
+		attrs = append(attrs, slog.Int("status", t.status))
+	}
+	if t.cache != "" {
+		attrs = append(attrs, slog.String("cache", t.cache))
+	}
+	if t.retries > 0 {
+		attrs = append(attrs, slog.Int("retries", t.retries))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("code", err.Code()), slog.String("error", err.Error()))
+		c.Logger.LogAttrs(context.Background(), slog.LevelWarn, "request failed", attrs...)
+		return
+	}
+
+	c.Logger.LogAttrs(context.Background(), slog.LevelDebug, "request", attrs...)
+}
+
+// logRetry writes a failed attempt at a request to the debug log before it is retried
+func (c Client) logRetry(pageURL string, attempt int, resp *http.Response, err error, wait time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("url", redactURL(pageURL)),
+		slog.Int("attempt", attempt),
+		slog.Duration("wait", wait),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	c.Logger.LogAttrs(context.Background(), slog.LevelDebug, "retrying request", attrs...)
+}
+
+// redactURL hides the password of URLs carrying credentials
+func redactURL(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "invalid URL"
+	}
+
+	return u.Redacted()
+}
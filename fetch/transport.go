@@ -0,0 +1,298 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// ErrorUnableToConfigureTransport is returned when the proxy or TLS settings cannot be applied
+const ErrorUnableToConfigureTransport = "UNABLE_TO_CONFIGURE_TRANSPORT"
+
+// tlsVersions maps the supported min_version settings to their TLS versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions control how the server certificate is verified and which client certificate is presented
+type TLSOptions struct {
+	// CABundle is a PEM file of certificates trusted in addition to the system ones, e.g. a corporate root
+	CABundle string `json:"ca_bundle,omitempty"`
+	// ClientCert and ClientKey are PEM files holding the certificate presented for mutual TLS
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// MinVersion is the oldest TLS version accepted: 1.0, 1.1, 1.2, or 1.3
+	MinVersion string `json:"min_version,omitempty"`
+	// InsecureSkipVerify turns off certificate verification; it is only allowed on a single site
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+func (t TLSOptions) isZero() bool {
+	return t == TLSOptions{}
+}
+
+func (t TLSOptions) merge(override TLSOptions) TLSOptions {
+	if override.CABundle != "" {
+		t.CABundle = override.CABundle
+	}
+	if override.ClientCert != "" || override.ClientKey != "" {
+		t.ClientCert = override.ClientCert
+		t.ClientKey = override.ClientKey
+	}
+	if override.MinVersion != "" {
+		t.MinVersion = override.MinVersion
+	}
+	if override.InsecureSkipVerify {
+		t.InsecureSkipVerify = true
+	}
+
+	return t
+}
+
+// config builds the TLS configuration on top of the one the transport already has
+func (t TLSOptions) config(base *tls.Config) (*tls.Config, error) {
+	var cfg *tls.Config
+	if base != nil {
+		cfg = base.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+
+	if t.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, rErr := os.ReadFile(expandHome(t.CABundle))
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in the CA bundle " + t.CABundle)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.ClientCert != "" || t.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(t.ClientCert), expandHome(t.ClientKey))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.MinVersion != "" {
+		v, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, errors.New("unsupported minimum TLS version " + t.MinVersion + ", expected 1.0, 1.1, 1.2, or 1.3")
+		}
+		cfg.MinVersion = v
+	}
+
+	// Only ever set by a site explicitly asking for it; the dropdown warns about every such site
+	cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || t.InsecureSkipVerify
+
+	return cfg, nil
+}
+
+func (o Options) hasTransportSettings() bool {
+	return o.Proxy != "" || o.NoProxy != "" || !o.TLS.isZero()
+}
+
+// proxy returns the proxy function for the transport; the environment is used unless a proxy is configured
+func (o Options) proxy() (func(*http.Request) (*url.URL, error), error) {
+	base := http.ProxyFromEnvironment
+
+	if o.Proxy != "" {
+		proxyURL, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, errors.New("invalid proxy URL")
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, errors.New("the proxy URL needs a scheme and a host, e.g. http://proxy.example.com:3128")
+		}
+		base = http.ProxyURL(proxyURL)
+	}
+
+	if o.NoProxy == "" {
+		return base, nil
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(o.NoProxy, req.URL) {
+			return nil, nil
+		}
+		return base(req)
+	}, nil
+}
+
+// bypassProxy reports whether the URL matches the comma separated no_proxy list. Entries are host names matching
+// themselves and their subdomains, optionally with a port, IP addresses or CIDR ranges, or * to bypass every host.
+func bypassProxy(noProxy string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(entryHost, ".")
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// configure applies the proxy and TLS settings to the round tripper. Transports we do not know how to configure, such
+// as the replay transport, are returned as they are.
+func (o Options) configure(rt http.RoundTripper) (http.RoundTripper, error) {
+	switch t := rt.(type) {
+	case nil:
+		return o.configure(http.DefaultTransport)
+	case *http.Transport:
+		configured := t.Clone()
+
+		proxy, err := o.proxy()
+		if err != nil {
+			return nil, err
+		}
+		configured.Proxy = proxy
+
+		tlsConfig, tErr := o.TLS.config(configured.TLSClientConfig)
+		if tErr != nil {
+			return nil, tErr
+		}
+		configured.TLSClientConfig = tlsConfig
+
+		return configured, nil
+	case RecordingTransport:
+		inner, err := o.configure(t.Transport)
+		if err != nil {
+			return nil, err
+		}
+		t.Transport = inner
+
+		return t, nil
+	default:
+		return rt, nil
+	}
+}
+
+// transportSettings are the options changing how connections are made; sites sharing them share an HTTP client
+type transportSettings struct {
+	proxy   string
+	noProxy string
+	tls     TLSOptions
+}
+
+func (o Options) transportSettings() transportSettings {
+	return transportSettings{proxy: o.Proxy, noProxy: o.NoProxy, tls: o.TLS}
+}
+
+// configuredClient is an HTTP client built for a set of transport settings, or why it could not be built
+type configuredClient struct {
+	client *http.Client
+	err    glitch.DataError
+}
+
+// clientKey identifies a configured client by the client it was built from and its settings
+type clientKey struct {
+	base     *http.Client
+	settings transportSettings
+}
+
+// clientCache keeps the HTTP clients built for each distinct set of transport settings so their connections are reused
+// from one site and request to the next; it is shared by the copies of a client
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[clientKey]configuredClient
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{clients: map[clientKey]configuredClient{}}
+}
+
+// get returns the client configured for the options, building it the first time the options are seen. Clients
+// without a cache build it every time.
+func (cc *clientCache) get(base *http.Client, o Options) configuredClient {
+	if cc == nil {
+		return configureClient(base, o)
+	}
+
+	key := clientKey{base: base, settings: o.transportSettings()}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	configured, ok := cc.clients[key]
+	if !ok {
+		configured = configureClient(base, o)
+		cc.clients[key] = configured
+	}
+
+	return configured
+}
+
+// configureClient returns a copy of the HTTP client applying the proxy and TLS settings
+func configureClient(base *http.Client, o Options) configuredClient {
+	hc := *base
+
+	rt, err := o.configure(hc.Transport)
+	if err != nil {
+		return configuredClient{err: glitch.NewDataError(err, ErrorUnableToConfigureTransport, "unable to apply the proxy and TLS settings")}
+	}
+	hc.Transport = rt
+
+	return configuredClient{client: &hc}
+}
+
+// httpClient returns the HTTP client to make requests with, applying the proxy and TLS settings when there are any
+func (c Client) httpClient() (*http.Client, glitch.DataError) {
+	if !c.Options.hasTransportSettings() {
+		return c.HTTPClient, nil
+	}
+
+	configured := c.configured
+	if configured == nil {
+		cc := c.clients.get(c.HTTPClient, c.Options)
+		configured = &cc
+	}
+
+	return configured.client, configured.err
+}
@@ -0,0 +1,182 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_BypassProxy(t *testing.T) {
+	tests := map[string]struct {
+		noProxy  string
+		rawURL   string
+		expected bool
+	}{
+		"base path- exact host":                 {noProxy: "status.example.com", rawURL: "https://status.example.com/api", expected: true},
+		"base path- subdomain":                  {noProxy: "example.com", rawURL: "https://status.example.com/api", expected: true},
+		"base path- leading dot":                {noProxy: ".example.com", rawURL: "https://status.example.com/api", expected: true},
+		"base path- host and port":              {noProxy: "internal:8443", rawURL: "https://internal:8443/health", expected: true},
+		"base path- CIDR":                       {noProxy: "10.0.0.0/8", rawURL: "http://10.1.2.3/health", expected: true},
+		"base path- wildcard":                   {noProxy: "*", rawURL: "https://status.example.com/api", expected: true},
+		"base path- list with spaces and case":  {noProxy: "localhost, Example.COM", rawURL: "https://status.example.com/api", expected: true},
+		"base path- different host":             {noProxy: "example.com", rawURL: "https://status.example.org/api", expected: false},
+		"base path- suffix is not a subdomain":  {noProxy: "example.com", rawURL: "https://notexample.com/api", expected: false},
+		"base path- different port":             {noProxy: "internal:8443", rawURL: "https://internal/health", expected: false},
+		"base path- host name outside the CIDR": {noProxy: "10.0.0.0/8", rawURL: "http://11.1.2.3/health", expected: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tc.rawURL)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, bypassProxy(tc.noProxy, u))
+		})
+	}
+}
+
+func TestUnit_Client_GetJSON_Proxy(t *testing.T) {
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.Host == "status.example.invalid"
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"via":"proxy"}`))
+	}))
+	defer proxy.Close()
+
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"via":"direct"}`))
+	}))
+	defer direct.Close()
+
+	directURL, err := url.Parse(direct.URL)
+	require.NoError(t, err)
+
+	c := NewClient(&http.Client{}).WithOptions(Options{Proxy: proxy.URL, NoProxy: directURL.Host})
+
+	var body map[string]string
+	require.NoError(t, c.GetJSON("http://status.example.invalid/api/v2/status.json", &body))
+	require.Equal(t, "proxy", body["via"])
+	require.True(t, proxied)
+
+	require.NoError(t, c.GetJSON(direct.URL+"/api/v2/status.json", &body))
+	require.Equal(t, "direct", body["via"])
+
+	bErr := NewClient(&http.Client{}).WithOptions(Options{Proxy: "proxy.example.com:3128"}).GetJSON(direct.URL, &body)
+	require.Error(t, bErr)
+	require.Equal(t, ErrorUnableToConfigureTransport, bErr.Code())
+}
+
+func TestUnit_Client_GetJSON_TLS(t *testing.T) {
+	var presented int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = len(r.TLS.PeerCertificates)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	// The untrusted certificate case fails the handshake on purpose
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	write := func(name string, block *pem.Block) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+		return path
+	}
+
+	// The test server's own certificate doubles as the CA bundle and the client certificate
+	caBundle := write("ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	key, err := x509.MarshalPKCS8PrivateKey(srv.TLS.Certificates[0].PrivateKey)
+	require.NoError(t, err)
+	clientKey := write("client.key", &pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	notPEM := write("not.pem", &pem.Block{Type: "NOTHING"})
+
+	tests := map[string]struct {
+		options           Options
+		expectedCode      string
+		expectedPresented int
+	}{
+		"base path- CA bundle": {
+			options: Options{TLS: TLSOptions{CABundle: caBundle, MinVersion: "1.2"}},
+		},
+		"base path- client certificate": {
+			options:           Options{TLS: TLSOptions{CABundle: caBundle, ClientCert: caBundle, ClientKey: clientKey}},
+			expectedPresented: 1,
+		},
+		"base path- skip verification": {
+			options: Options{TLS: TLSOptions{InsecureSkipVerify: true}},
+		},
+		"exceptional path- untrusted certificate": {
+			options:      Options{TLS: TLSOptions{MinVersion: "1.2"}},
+			expectedCode: whatsupstatus.ErrorUnableToMakeClientRequest,
+		},
+		"exceptional path- CA bundle without certificates": {
+			options:      Options{TLS: TLSOptions{CABundle: notPEM}},
+			expectedCode: ErrorUnableToConfigureTransport,
+		},
+		"exceptional path- CA bundle missing": {
+			options:      Options{TLS: TLSOptions{CABundle: filepath.Join(dir, "missing.pem")}},
+			expectedCode: ErrorUnableToConfigureTransport,
+		},
+		"exceptional path- client key missing": {
+			options:      Options{TLS: TLSOptions{CABundle: caBundle, ClientCert: caBundle}},
+			expectedCode: ErrorUnableToConfigureTransport,
+		},
+		"exceptional path- unsupported minimum version": {
+			options:      Options{TLS: TLSOptions{MinVersion: "1.4"}},
+			expectedCode: ErrorUnableToConfigureTransport,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			presented = 0
+
+			var body map[string]string
+			err := NewClient(&http.Client{}).WithOptions(tc.options).GetJSON(srv.URL, &body)
+			if tc.expectedCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedCode, err.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "ok", body["status"])
+			require.Equal(t, tc.expectedPresented, presented)
+		})
+	}
+}
+
+func TestUnit_Client_WithOptions_SharesHTTPClients(t *testing.T) {
+	c := NewClient(&http.Client{})
+
+	httpClient := func(c Client) *http.Client {
+		hc, err := c.httpClient()
+		require.NoError(t, err)
+		return hc
+	}
+
+	plain := httpClient(c.WithOptions(Options{Interval: Duration(time.Minute)}))
+	require.Same(t, c.HTTPClient, plain)
+
+	proxied := httpClient(c.WithOptions(Options{Proxy: "http://proxy.example.com:3128"}))
+	require.NotSame(t, c.HTTPClient, proxied)
+
+	// Sites with the same proxy and TLS settings share the client, and with it the connections it keeps open
+	require.Same(t, proxied, httpClient(c.WithOptions(Options{Proxy: "http://proxy.example.com:3128", MaxBodySize: 10})))
+	require.Same(t, proxied, httpClient(c.WithOptions(Options{Proxy: "http://proxy.example.com:3128"}).WithOptions(Options{Interval: Duration(time.Minute)})))
+	require.NotSame(t, proxied, httpClient(c.WithOptions(Options{Proxy: "http://proxy.example.com:8080"})))
+}
@@ -0,0 +1,80 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// Error codes for responses that cannot be status payloads
+const (
+	ErrorUnexpectedContentType = "UNEXPECTED_CONTENT_TYPE"
+	ErrorResponseTooLarge      = "RESPONSE_TOO_LARGE"
+	ErrorHTMLReceived          = "HTML_RECEIVED"
+)
+
+// utf8BOM is dropped from the start of a response; some servers send one and the JSON decoder rejects it
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// readBody reads at most maxSize bytes of the response, failing when the response is larger
+func readBody(resp *http.Response, pageURL string, maxSize int64) ([]byte, glitch.DataError) {
+	if resp.ContentLength > maxSize {
+		return nil, tooLarge(pageURL, maxSize)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, glitch.NewDataError(err, ErrorUnableToParseResponse, "unable to read the response from "+pageURL)
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, tooLarge(pageURL, maxSize)
+	}
+
+	return bytes.TrimPrefix(body, utf8BOM), nil
+}
+
+func tooLarge(pageURL string, maxSize int64) glitch.DataError {
+	return glitch.NewDataError(nil, ErrorResponseTooLarge, fmt.Sprintf("the response from %s is larger than %d bytes", pageURL, maxSize))
+}
+
+// validateJSON makes sure the response looks like a JSON document, catching misconfigured URLs that point at web pages
+// or files instead of a status API
+func validateJSON(resp *http.Response, body []byte, pageURL string) glitch.DataError {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+
+	var first byte
+	if len(trimmed) > 0 {
+		first = trimmed[0]
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case first == '<' || mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return glitch.NewDataError(nil, ErrorHTMLReceived, pageURL+" returned an HTML page instead of JSON")
+	case !isJSONMediaType(mediaType):
+		return glitch.NewDataError(nil, ErrorUnexpectedContentType, fmt.Sprintf("%s returned content type %q instead of JSON", pageURL, contentType))
+	case first != '{' && first != '[':
+		return glitch.NewDataError(nil, ErrorUnexpectedContentType, pageURL+" did not return a JSON object or array")
+	}
+
+	return nil
+}
+
+// isJSONMediaType allows JSON media types along with the generic ones some status pages serve JSON under
+func isJSONMediaType(mediaType string) bool {
+	switch {
+	case mediaType == "", mediaType == "application/json", mediaType == "text/json", mediaType == "text/plain",
+		mediaType == "application/octet-stream", mediaType == "application/javascript", mediaType == "text/javascript":
+		return true
+	default:
+		return strings.HasSuffix(mediaType, "+json")
+	}
+}
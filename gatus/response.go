@@ -0,0 +1,181 @@
+// Package gatus handles communicating with self-hosted Gatus instances
+package gatus
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "gatus"
+
+// statusesPath lists every endpoint with its recent results, relative to where Gatus is installed
+const statusesPath = "/api/v1/endpoints/statuses"
+
+// Result is a single check of an endpoint
+type Result struct {
+	Status    int       `json:"status"`
+	Success   bool      `json:"success"`
+	Timestamp time.Time `json:"timestamp"`
+	Errors    []string  `json:"errors"`
+}
+
+// Endpoint is an endpoint monitored by Gatus along with its recent results
+type Endpoint struct {
+	Name    string   `json:"name"`
+	Group   string   `json:"group"`
+	Key     string   `json:"key"`
+	Results []Result `json:"results"`
+}
+
+// Title returns the endpoint name along with its group, e.g. API (core)
+func (e Endpoint) Title() string {
+	if e.Group == "" {
+		return e.Name
+	}
+
+	return e.Name + " (" + e.Group + ")"
+}
+
+// Latest returns the most recent result, or nil when the endpoint has not been checked yet
+func (e Endpoint) Latest() *Result {
+	var latest *Result
+	for i := range e.Results {
+		if latest == nil || !e.Results[i].Timestamp.Before(latest.Timestamp) {
+			latest = &e.Results[i]
+		}
+	}
+
+	return latest
+}
+
+// Indicator reports an endpoint failing its latest check as major
+func (e Endpoint) Indicator() string {
+	if latest := e.Latest(); latest != nil && !latest.Success {
+		return "major"
+	}
+
+	return "none"
+}
+
+// StatusText describes the endpoint's latest check
+func (e Endpoint) StatusText() string {
+	latest := e.Latest()
+	switch {
+	case latest == nil:
+		return "No data"
+	case latest.Success:
+		return "Healthy"
+	default:
+		return "Unhealthy"
+	}
+}
+
+// Response holds the endpoints of a Gatus instance, rolled up into one service
+type Response struct {
+	Endpoints []Endpoint
+
+	name    string
+	pageURL string
+}
+
+// Indicator returns major when any endpoint fails its latest check
+func (r Response) Indicator() string {
+	for _, e := range r.Endpoints {
+		if e.Indicator() == "major" {
+			return "major"
+		}
+	}
+
+	return "none"
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when an endpoint was last checked
+func (r Response) UpdatedAt() time.Time {
+	var latest time.Time
+	for _, e := range r.Endpoints {
+		if l := e.Latest(); l != nil && l.Timestamp.After(latest) {
+			latest = l.Timestamp
+		}
+	}
+
+	return latest
+}
+
+// URL returns the Gatus dashboard
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists the endpoints with the result of their latest check
+func (r Response) Summary() []string {
+	lines := make([]string, 0, len(r.Endpoints))
+	for _, e := range r.Endpoints {
+		lines = append(lines, e.Title()+": "+e.StatusText())
+	}
+
+	return lines
+}
+
+// Expand lists every endpoint as a service of its own, named after the site and the endpoint
+func (r Response) Expand() []whatsupstatus.Details {
+	parts := make([]whatsupstatus.Details, 0, len(r.Endpoints))
+	for _, e := range r.Endpoints {
+		part := status.Part{
+			PartName:      r.name + " / " + e.Title(),
+			PartIndicator: e.Indicator(),
+			PartURL:       r.pageURL,
+		}
+		if e.Key != "" {
+			part.PartURL = r.pageURL + "/endpoints/" + e.Key
+		}
+		if l := e.Latest(); l != nil {
+			part.PartUpdatedAt = l.Timestamp
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// ClientReader implements the Reader interface for Gatus. PageURL is where Gatus is installed, e.g.
+// https://gatus.example.com, or its endpoint statuses API.
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(strings.TrimSuffix(cr.PageURL, "/"), statusesPath)
+
+	var endpoints []Endpoint
+
+	err = g.GetJSON(base+statusesPath, &endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(endpoints) == 0 {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, base+statusesPath+" did not list any endpoints")
+	}
+
+	return Response{Endpoints: endpoints, name: cr.ServiceName, pageURL: base}, nil
+}
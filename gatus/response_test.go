@@ -0,0 +1,116 @@
+package gatus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Endpoint_Latest(t *testing.T) {
+	older := time.Date(2024, time.March, 4, 14, 58, 0, 0, time.UTC)
+	newer := older.Add(time.Minute)
+
+	tests := map[string]struct {
+		endpoint          Endpoint
+		expectedIndicator string
+		expectedText      string
+	}{
+		"base path- healthy": {
+			endpoint:          Endpoint{Results: []Result{{Success: false, Timestamp: older}, {Success: true, Timestamp: newer}}},
+			expectedIndicator: "none",
+			expectedText:      "Healthy",
+		},
+		"base path- results newest first": {
+			endpoint:          Endpoint{Results: []Result{{Success: false, Timestamp: newer}, {Success: true, Timestamp: older}}},
+			expectedIndicator: "major",
+			expectedText:      "Unhealthy",
+		},
+		"base path- not checked yet": {
+			endpoint:          Endpoint{},
+			expectedIndicator: "none",
+			expectedText:      "No data",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expectedIndicator, tc.endpoint.Indicator())
+			require.Equal(t, tc.expectedText, tc.endpoint.StatusText())
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- dashboard URL": {
+			reader: ClientReader{ServiceName: "Platform", PageURL: "https://gatus.example.com/"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, "Platform", details.Name())
+				require.Equal(t, "https://gatus.example.com", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 59, 30, 511000000, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"api (core): Healthy",
+					"queue (core): Unhealthy",
+					"docs: Healthy",
+				}, details.(status.Summarizer).Summary())
+
+				parts := details.(status.Expander).Expand()
+				require.Len(t, parts, 3)
+				require.Equal(t, "Platform / queue (core)", parts[1].Name())
+				require.Equal(t, "major", parts[1].Indicator())
+				require.Equal(t, "https://gatus.example.com/endpoints/core_queue", parts[1].URL())
+				require.Equal(t, "none", parts[2].Indicator())
+			},
+		},
+		"base path- statuses API URL": {
+			reader: ClientReader{ServiceName: "Platform", PageURL: "https://gatus.example.com/api/v1/endpoints/statuses"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "https://gatus.example.com", details.URL())
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://gatus.unknown.dev"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://gatus.unknown.dev/api/v1/endpoints/statuses"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Platform", PageURL: "https://gatus.example.com"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`[]`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
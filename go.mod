@@ -7,6 +7,7 @@ toolchain go1.21.1
 require github.com/sprak3000/go-client v1.1.0
 
 require (
+	github.com/golang/mock v1.6.0
 	github.com/sprak3000/go-glitch v1.1.0
 	github.com/sprak3000/go-whatsup-client v1.2.0
 	github.com/stretchr/testify v1.9.0
@@ -14,7 +15,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/golang/mock v1.6.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,245 @@
+// Package googlecloud handles reading the incidents Google Cloud publishes for its products
+package googlecloud
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "googlecloud"
+
+// IncidentsURL is where Google Cloud publishes its incidents
+const IncidentsURL = "https://status.cloud.google.com/incidents.json"
+
+// Incident severities reported by Google Cloud
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// GlobalLocation is where Google Cloud places incidents affecting every location
+const GlobalLocation = "global"
+
+// Indicator maps an incident severity onto our severities
+func Indicator(severity string) string {
+	if severity == SeverityHigh {
+		return "major"
+	}
+
+	return "minor"
+}
+
+// Reference names a product or location affected by an incident
+type Reference struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// matches reports whether the reference has one of the given IDs or titles
+func (r Reference) matches(wanted []string) bool {
+	for _, w := range wanted {
+		if strings.EqualFold(r.ID, w) || strings.EqualFold(r.Title, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Update is a status update posted to an incident
+type Update struct {
+	Modified          time.Time   `json:"modified"`
+	Status            string      `json:"status"`
+	AffectedLocations []Reference `json:"affected_locations"`
+}
+
+// Incident is an incident reported by Google Cloud
+type Incident struct {
+	ID                         string      `json:"id"`
+	Number                     string      `json:"number"`
+	Begin                      time.Time   `json:"begin"`
+	End                        *time.Time  `json:"end"`
+	Modified                   time.Time   `json:"modified"`
+	ExternalDesc               string      `json:"external_desc"`
+	Severity                   string      `json:"severity"`
+	StatusImpact               string      `json:"status_impact"`
+	ServiceName                string      `json:"service_name"`
+	AffectedProducts           []Reference `json:"affected_products"`
+	CurrentlyAffectedLocations []Reference `json:"currently_affected_locations"`
+	MostRecentUpdate           Update      `json:"most_recent_update"`
+}
+
+// Open reports whether the incident has not ended yet
+func (i Incident) Open() bool {
+	return i.End == nil || i.End.IsZero()
+}
+
+// Locations returns the locations the incident currently affects, or those of its latest update when the incident does
+// not list them
+func (i Incident) Locations() []Reference {
+	if len(i.CurrentlyAffectedLocations) > 0 {
+		return i.CurrentlyAffectedLocations
+	}
+
+	return i.MostRecentUpdate.AffectedLocations
+}
+
+// Title returns the first line of the incident description
+func (i Incident) Title() string {
+	title, _, _ := strings.Cut(strings.TrimSpace(i.ExternalDesc), "\n")
+	return title
+}
+
+// Response holds the open incidents affecting the configured products and regions
+type Response struct {
+	Incidents []Incident
+
+	name      string
+	pageURL   string
+	updatedAt time.Time
+}
+
+// Indicator returns the most severe of the open incidents
+func (r Response) Indicator() string {
+	indicator := "none"
+	for _, i := range r.Incidents {
+		if Indicator(i.Severity) == "major" {
+			return "major"
+		}
+		indicator = "minor"
+	}
+
+	return indicator
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when the incidents were last modified
+func (r Response) UpdatedAt() time.Time {
+	return r.updatedAt
+}
+
+// URL returns the status dashboard
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists each open incident with the products and locations it affects
+func (r Response) Summary() []string {
+	var lines []string
+	for _, i := range r.Incidents {
+		lines = append(lines, "Incident: "+i.Title())
+		if products := titles(i.AffectedProducts); products != "" {
+			lines = append(lines, "Products: "+products)
+		}
+		if locations := titles(i.Locations()); locations != "" {
+			lines = append(lines, "Locations: "+locations)
+		}
+	}
+
+	return lines
+}
+
+func titles(refs []Reference) string {
+	names := make([]string, 0, len(refs))
+	for _, r := range refs {
+		names = append(names, r.Title)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// ClientReader implements the Reader interface for the Google Cloud incidents, e.g. IncidentsURL
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+	// Products and Regions limit the incidents to those affecting one of them, by ID or title; every incident counts
+	// when not set
+	Products []string
+	Regions  []string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []Incident
+
+	err = g.GetJSON(cr.PageURL, &incidents)
+	if err != nil {
+		return nil, err
+	}
+
+	if incidents == nil {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, cr.PageURL+" did not list any incidents")
+	}
+
+	resp := Response{
+		name:    cr.ServiceName,
+		pageURL: strings.TrimSuffix(cr.PageURL, "incidents.json"),
+	}
+
+	var feedUpdatedAt time.Time
+	for _, i := range incidents {
+		if i.Modified.After(feedUpdatedAt) {
+			feedUpdatedAt = i.Modified
+		}
+
+		if !cr.affects(i) {
+			continue
+		}
+
+		if i.Modified.After(resp.updatedAt) {
+			resp.updatedAt = i.Modified
+		}
+
+		if i.Open() {
+			resp.Incidents = append(resp.Incidents, i)
+		}
+	}
+
+	// Nothing touching the products and regions changed; the feed itself was last updated by its newest incident
+	if resp.updatedAt.IsZero() {
+		resp.updatedAt = feedUpdatedAt
+	}
+
+	return resp, nil
+}
+
+// affects reports whether the incident touches one of the configured products and regions; global incidents touch
+// every region
+func (cr ClientReader) affects(i Incident) bool {
+	if !affected(i.AffectedProducts, cr.Products) {
+		return false
+	}
+
+	return affected(i.Locations(), cr.Regions) || affected(i.Locations(), []string{GlobalLocation})
+}
+
+func affected(refs []Reference, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+
+	for _, r := range refs {
+		if r.matches(wanted) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package googlecloud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Indicator(t *testing.T) {
+	tests := map[string]string{
+		SeverityLow:    "minor",
+		SeverityMedium: "minor",
+		SeverityHigh:   "major",
+		"":             "minor",
+	}
+	for severity, expected := range tests {
+		require.Equal(t, expected, Indicator(severity), "severity %q", severity)
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- every open incident": {
+			reader: ClientReader{ServiceName: "Google Cloud", PageURL: IncidentsURL},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, "Google Cloud", details.Name())
+				require.Equal(t, "https://status.cloud.google.com/", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 55, 3, 0, time.UTC), details.UpdatedAt().UTC())
+				require.Equal(t, []string{
+					"Incident: Cloud Run deployments are failing in us-central1",
+					"Products: Cloud Run",
+					"Locations: Iowa (us-central1)",
+					"Incident: Increased query latency for BigQuery in the EU multi-region",
+					"Products: Google BigQuery",
+					"Locations: Multi-region: eu, Belgium (europe-west1)",
+					"Incident: Cloud Console pages load slowly",
+					"Products: Google Cloud Console",
+					"Locations: Global",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- filtered by product title and region": {
+			reader: ClientReader{ServiceName: "BigQuery EU", PageURL: IncidentsURL, Products: []string{"google bigquery"}, Regions: []string{"europe-west1"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, []string{
+					"Incident: Increased query latency for BigQuery in the EU multi-region",
+					"Products: Google BigQuery",
+					"Locations: Multi-region: eu, Belgium (europe-west1)",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- updated when an incident of the product last changed": {
+			reader: ClientReader{ServiceName: "BigQuery", PageURL: IncidentsURL, Products: []string{"Google BigQuery"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, time.Date(2024, time.March, 4, 12, 10, 19, 0, time.UTC), details.UpdatedAt().UTC())
+			},
+		},
+		"base path- closed incidents are not counted": {
+			reader: ClientReader{ServiceName: "Compute", PageURL: IncidentsURL, Products: []string{"L3ggmi3Jy4xJmgodFA9K"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Empty(t, details.(status.Summarizer).Summary())
+				require.Equal(t, time.Date(2024, time.March, 3, 22, 0, 0, 0, time.UTC), details.UpdatedAt().UTC())
+			},
+		},
+		"base path- global incidents affect every region": {
+			reader: ClientReader{ServiceName: "Tokyo", PageURL: IncidentsURL, Regions: []string{"asia-northeast1"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, time.Date(2024, time.March, 4, 11, 15, 0, 0, time.UTC), details.UpdatedAt().UTC())
+				require.Equal(t, []string{
+					"Incident: Cloud Console pages load slowly",
+					"Products: Google Cloud Console",
+					"Locations: Global",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- no incidents in the region": {
+			reader: ClientReader{ServiceName: "Cloud Run Tokyo", PageURL: IncidentsURL, Products: []string{"Cloud Run"}, Regions: []string{"asia-northeast1"}},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 55, 3, 0, time.UTC), details.UpdatedAt().UTC())
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://status.example.com/incidents.json"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://status.example.com/incidents.json"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Google Cloud", PageURL: IncidentsURL}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"incidents":[]}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	details, err = reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`[]`)))
+	require.NoError(t, err)
+	require.Equal(t, "none", details.Indicator())
+	require.True(t, details.UpdatedAt().IsZero())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
@@ -0,0 +1,95 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// ErrorUnsupportedFormat is returned when asked to write a report in a format we do not know
+const ErrorUnsupportedFormat = "UNSUPPORTED_REPORT_FORMAT"
+
+// Report formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+var columns = []string{"name", "kind", "window", "polls", "none", "minor", "major", "incidents", "mttr", "longest_outage"}
+
+// Write outputs the report in the given format
+func (r Report) Write(w io.Writer, format string) glitch.DataError {
+	var err error
+
+	switch format {
+	case FormatTable, "":
+		err = r.writeTable(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	case FormatCSV:
+		err = r.writeCSV(w)
+	default:
+		return glitch.NewDataError(nil, ErrorUnsupportedFormat, "unsupported report format "+format)
+	}
+
+	if err != nil {
+		return glitch.NewDataError(err, ErrorUnableToWriteHistory, "unable to write What's Up report")
+	}
+
+	return nil
+}
+
+func (r Report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "NAME\tKIND\tWINDOW\tPOLLS\tNONE\tMINOR\tMAJOR\tINCIDENTS\tMTTR\tLONGEST OUTAGE")
+	for _, s := range r {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.2f%%\t%.2f%%\t%.2f%%\t%d\t%s\t%s\n", s.Name, s.Kind, s.Window, s.Polls, s.None, s.Minor, s.Major, s.Incidents, s.MTTR, s.LongestOutage)
+	}
+
+	return tw.Flush()
+}
+
+func (r Report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write(columns)
+	for _, s := range r {
+		_ = cw.Write([]string{
+			s.Name,
+			s.Kind,
+			s.Window,
+			strconv.Itoa(s.Polls),
+			strconv.FormatFloat(s.None, 'f', 2, 64),
+			strconv.FormatFloat(s.Minor, 'f', 2, 64),
+			strconv.FormatFloat(s.Major, 'f', 2, 64),
+			strconv.Itoa(s.Incidents),
+			s.MTTR.String(),
+			s.LongestOutage.String(),
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// String renders the duration rounded to the minute, e.g. "1h30m"
+func (d Duration) String() string {
+	rounded := time.Duration(d).Round(time.Minute)
+	if rounded == 0 {
+		return "0m"
+	}
+
+	s := rounded.String()
+	// Drop the trailing "0s" time.Duration always includes
+	return s[:len(s)-2]
+}
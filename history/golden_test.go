@@ -0,0 +1,42 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/internal/golden"
+)
+
+func TestUnit_Report_Write_Golden(t *testing.T) {
+	now := time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)
+
+	var polls Polls
+	for h := 24 * 30; h > 0; h-- {
+		indicator := "none"
+		switch {
+		case h%100 < 3:
+			indicator = "major"
+		case h%37 == 0:
+			indicator = "minor"
+		}
+
+		polls = append(polls,
+			Poll{Time: now.Add(-time.Duration(h) * time.Hour), Site: "CircleCI", Group: "ci", Indicator: indicator},
+			Poll{Time: now.Add(-time.Duration(h) * time.Hour), Site: "GitHub", Group: "ci", Indicator: "none"},
+			Poll{Time: now.Add(-time.Duration(h) * time.Hour), Site: "Café ☕", Indicator: "none"},
+		)
+	}
+
+	report := polls.Report(now)
+
+	for _, format := range []string{FormatTable, FormatJSON, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, report.Write(&buf, format))
+			golden.Require(t, buf.Bytes())
+		})
+	}
+}
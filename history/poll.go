@@ -0,0 +1,98 @@
+// Package history keeps the status reported by every site on each run so we can tell how reliable they are
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+)
+
+// Error codes
+const (
+	ErrorUnableToParseHistory = "UNABLE_TO_PARSE_HISTORY"
+	ErrorUnableToWriteHistory = "UNABLE_TO_WRITE_HISTORY"
+)
+
+// Retention is how long we keep polls around; it matches the longest reporting window
+const Retention = 30 * 24 * time.Hour
+
+// Poll is the status a site reported on a single run
+type Poll struct {
+	Time      time.Time `json:"time"`
+	Site      string    `json:"site"`
+	Group     string    `json:"group,omitempty"`
+	Indicator string    `json:"indicator"`
+}
+
+// Polls is the list of every poll we have kept, oldest first
+type Polls []Poll
+
+// Load reads the history from disk, one JSON encoded poll per line; a missing file means there is no history yet
+func Load(r configuration.Reader, filename string) (Polls, glitch.DataError) {
+	var polls Polls
+
+	data, rErr := r.ReadFile(filename)
+	if rErr != nil {
+		return polls, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var p Poll
+		uErr := json.Unmarshal(scanner.Bytes(), &p)
+		if uErr != nil {
+			return nil, glitch.NewDataError(uErr, ErrorUnableToParseHistory, "error parsing What's Up history")
+		}
+		polls = append(polls, p)
+	}
+
+	sErr := scanner.Err()
+	if sErr != nil {
+		return nil, glitch.NewDataError(sErr, ErrorUnableToParseHistory, "error reading What's Up history")
+	}
+
+	return polls, nil
+}
+
+// Save writes the history to disk, one JSON encoded poll per line
+func (p Polls) Save(w configuration.Writer, filename string) glitch.DataError {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	for _, poll := range p {
+		eErr := enc.Encode(poll)
+		if eErr != nil {
+			return glitch.NewDataError(eErr, ErrorUnableToWriteHistory, "unable to encode What's Up history")
+		}
+	}
+
+	wErr := w.WriteFile(filename, buf.Bytes(), 0644)
+	if wErr != nil {
+		return glitch.NewDataError(wErr, ErrorUnableToWriteHistory, "unable to write What's Up history")
+	}
+
+	return nil
+}
+
+// Prune drops every poll older than the retention period
+func (p Polls) Prune(now time.Time) Polls {
+	cutoff := now.Add(-Retention)
+
+	kept := Polls{}
+	for _, poll := range p {
+		if !poll.Time.Before(cutoff) {
+			kept = append(kept, poll)
+		}
+	}
+
+	return kept
+}
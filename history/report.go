@@ -0,0 +1,188 @@
+package history
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/sprak3000/xbar-whats-up/internal/mapkeys"
+)
+
+// Summary kinds
+const (
+	KindSite  = "site"
+	KindGroup = "group"
+)
+
+// Window is a rolling period we report on
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows are the periods every report covers
+var Windows = []Window{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: Retention},
+}
+
+// Duration is a time.Duration encoded as a human readable string, e.g. "1h30m"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Summary holds the reliability statistics of a site or group of sites over a window
+type Summary struct {
+	Name          string   `json:"name"`
+	Kind          string   `json:"kind"`
+	Window        string   `json:"window"`
+	Polls         int      `json:"polls"`
+	None          float64  `json:"none"`
+	Minor         float64  `json:"minor"`
+	Major         float64  `json:"major"`
+	Incidents     int      `json:"incidents"`
+	MTTR          Duration `json:"mttr"`
+	LongestOutage Duration `json:"longest_outage"`
+}
+
+// Report holds the summaries for every site and group over every window
+type Report []Summary
+
+// Window returns the site summaries for the named window
+func (r Report) Window(name string) Report {
+	var sites Report
+
+	for _, s := range r {
+		if s.Window == name && s.Kind == KindSite {
+			sites = append(sites, s)
+		}
+	}
+
+	return sites
+}
+
+// incident is a stretch of polls where a site reported issues
+type incident struct {
+	duration time.Duration
+	resolved bool
+}
+
+// tally accumulates the polls and incidents of a site or group
+type tally struct {
+	polls     int
+	counts    map[string]int
+	incidents []incident
+}
+
+func (t *tally) add(polls Polls, now time.Time) {
+	if t.counts == nil {
+		t.counts = map[string]int{}
+	}
+
+	var start time.Time
+	inIncident := false
+
+	for _, p := range polls {
+		t.polls++
+		t.counts[p.Indicator]++
+
+		switch {
+		case p.Indicator != "none" && !inIncident:
+			inIncident = true
+			start = p.Time
+		case p.Indicator == "none" && inIncident:
+			inIncident = false
+			t.incidents = append(t.incidents, incident{duration: p.Time.Sub(start), resolved: true})
+		}
+	}
+
+	if inIncident {
+		t.incidents = append(t.incidents, incident{duration: now.Sub(start)})
+	}
+}
+
+func (t tally) summary(name, kind, window string) Summary {
+	s := Summary{
+		Name:      name,
+		Kind:      kind,
+		Window:    window,
+		Polls:     t.polls,
+		Incidents: len(t.incidents),
+	}
+
+	if t.polls > 0 {
+		s.None = percent(t.counts["none"], t.polls)
+		s.Minor = percent(t.counts["minor"], t.polls)
+		s.Major = percent(t.counts["major"], t.polls)
+	}
+
+	var resolved int
+	var total time.Duration
+	for _, i := range t.incidents {
+		if i.resolved {
+			resolved++
+			total += i.duration
+		}
+		if Duration(i.duration) > s.LongestOutage {
+			s.LongestOutage = Duration(i.duration)
+		}
+	}
+
+	if resolved > 0 {
+		s.MTTR = Duration(total / time.Duration(resolved))
+	}
+
+	return s
+}
+
+func percent(n, total int) float64 {
+	return float64(n) * 100 / float64(total)
+}
+
+// Report summarizes the polls per site and per group over every window
+func (p Polls) Report(now time.Time) Report {
+	var report Report
+
+	for _, w := range Windows {
+		cutoff := now.Add(-w.Duration)
+
+		bySite := map[string]Polls{}
+		groups := map[string]string{}
+		for _, poll := range p {
+			if poll.Time.Before(cutoff) || poll.Time.After(now) {
+				continue
+			}
+			bySite[poll.Site] = append(bySite[poll.Site], poll)
+			if poll.Group != "" {
+				groups[poll.Site] = poll.Group
+			}
+		}
+
+		byGroup := map[string]*tally{}
+		for _, site := range mapkeys.Sorted(bySite) {
+			polls := bySite[site]
+			sort.SliceStable(polls, func(i, j int) bool { return polls[i].Time.Before(polls[j].Time) })
+
+			var t tally
+			t.add(polls, now)
+			report = append(report, t.summary(site, KindSite, w.Name))
+
+			if g, ok := groups[site]; ok {
+				if byGroup[g] == nil {
+					byGroup[g] = &tally{}
+				}
+				byGroup[g].add(polls, now)
+			}
+		}
+
+		for _, g := range mapkeys.Sorted(byGroup) {
+			report = append(report, byGroup[g].summary(g, KindGroup, w.Name))
+		}
+	}
+
+	return report
+}
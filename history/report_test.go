@@ -0,0 +1,88 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Polls_Report(t *testing.T) {
+	now := time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	polls := Polls{
+		// Resolved two hour incident two days ago
+		{Time: hoursAgo(50), Site: "CircleCI", Group: "ci", Indicator: "none"},
+		{Time: hoursAgo(49), Site: "CircleCI", Group: "ci", Indicator: "major"},
+		{Time: hoursAgo(48), Site: "CircleCI", Group: "ci", Indicator: "minor"},
+		{Time: hoursAgo(47), Site: "CircleCI", Group: "ci", Indicator: "none"},
+		// Ongoing incident that started an hour ago
+		{Time: hoursAgo(2), Site: "GitHub", Group: "ci", Indicator: "none"},
+		{Time: hoursAgo(1), Site: "GitHub", Group: "ci", Indicator: "minor"},
+		// Outside of every window
+		{Time: hoursAgo(24 * 40), Site: "Slack", Indicator: "major"},
+		{Time: hoursAgo(3), Site: "Slack", Indicator: "none"},
+	}
+
+	report := polls.Report(now)
+
+	require.Equal(t, Report{
+		{Name: "GitHub", Kind: KindSite, Window: "24h", Polls: 2, None: 50, Minor: 50, Incidents: 1, LongestOutage: Duration(time.Hour)},
+		{Name: "Slack", Kind: KindSite, Window: "24h", Polls: 1, None: 100},
+		{Name: "ci", Kind: KindGroup, Window: "24h", Polls: 2, None: 50, Minor: 50, Incidents: 1, LongestOutage: Duration(time.Hour)},
+	}, report[:3])
+
+	require.Equal(t, Report{
+		{Name: "CircleCI", Kind: KindSite, Window: "7d", Polls: 4, None: 50, Minor: 25, Major: 25, Incidents: 1, MTTR: Duration(2 * time.Hour), LongestOutage: Duration(2 * time.Hour)},
+		{Name: "GitHub", Kind: KindSite, Window: "7d", Polls: 2, None: 50, Minor: 50, Incidents: 1, LongestOutage: Duration(time.Hour)},
+		{Name: "Slack", Kind: KindSite, Window: "7d", Polls: 1, None: 100},
+	}, report.Window("7d"))
+
+	require.Len(t, report, 11)
+}
+
+func TestUnit_Report_Write(t *testing.T) {
+	report := Report{
+		{Name: "CircleCI", Kind: KindSite, Window: "7d", Polls: 4, None: 50, Minor: 25, Major: 25, Incidents: 1, MTTR: Duration(90 * time.Minute), LongestOutage: Duration(2 * time.Hour)},
+	}
+
+	tests := map[string]struct {
+		format         string
+		expectedOutput string
+		expectedErr    glitch.DataError
+	}{
+		"base path- table": {
+			format: FormatTable,
+			expectedOutput: "NAME      KIND  WINDOW  POLLS  NONE    MINOR   MAJOR   INCIDENTS  MTTR   LONGEST OUTAGE\n" +
+				"CircleCI  site  7d      4      50.00%  25.00%  25.00%  1          1h30m  2h0m\n",
+		},
+		"base path- json": {
+			format:         FormatJSON,
+			expectedOutput: "[\n  {\n    \"name\": \"CircleCI\",\n    \"kind\": \"site\",\n    \"window\": \"7d\",\n    \"polls\": 4,\n    \"none\": 50,\n    \"minor\": 25,\n    \"major\": 25,\n    \"incidents\": 1,\n    \"mttr\": \"1h30m\",\n    \"longest_outage\": \"2h0m\"\n  }\n]\n",
+		},
+		"base path- csv": {
+			format:         FormatCSV,
+			expectedOutput: "name,kind,window,polls,none,minor,major,incidents,mttr,longest_outage\nCircleCI,site,7d,4,50.00,25.00,25.00,1,1h30m,2h0m\n",
+		},
+		"exceptional path- unsupported format": {
+			format:      "xml",
+			expectedErr: glitch.NewDataError(nil, ErrorUnsupportedFormat, "unsupported report format xml"),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := report.Write(&buf, tc.format)
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErr.Code(), err.Code())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
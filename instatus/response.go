@@ -0,0 +1,164 @@
+// Package instatus handles communicating with status pages hosted on Instatus
+package instatus
+
+import (
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "instatus"
+
+// Page states reported by Instatus
+const (
+	PageUp                 = "UP"
+	PageHasIssues          = "HASISSUES"
+	PageUnderMaintenance   = "UNDERMAINTENANCE"
+	ImpactDegraded         = "DEGRADEDPERFORMANCE"
+	ImpactPartialOutage    = "PARTIALOUTAGE"
+	ImpactMajorOutage      = "MAJOROUTAGE"
+	ImpactUnderMaintenance = "UNDERMAINTENANCE"
+)
+
+// Page describes the status page
+type Page struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Status string `json:"status"`
+}
+
+// Incident is an incident or maintenance in progress
+type Incident struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+	Status  string    `json:"status"`
+	Impact  string    `json:"impact"`
+	URL     string    `json:"url"`
+}
+
+// Maintenance is a maintenance in progress
+type Maintenance struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Start  time.Time `json:"start"`
+	Status string    `json:"status"`
+	URL    string    `json:"url"`
+}
+
+// Response is what Instatus returns for /summary.json
+type Response struct {
+	Page               Page          `json:"page"`
+	ActiveIncidents    []Incident    `json:"activeIncidents"`
+	ActiveMaintenances []Maintenance `json:"activeMaintenances"`
+
+	name      string
+	updatedAt time.Time
+}
+
+// Indicator returns the most severe of the page state and the impact of its incidents
+func (r Response) Indicator() string {
+	indicator := "none"
+	switch r.Page.Status {
+	case PageHasIssues:
+		indicator = "minor"
+	case PageUnderMaintenance:
+		indicator = status.IndicatorMaintenance
+	}
+
+	for _, i := range r.ActiveIncidents {
+		switch i.Impact {
+		case ImpactMajorOutage:
+			return "major"
+		case ImpactDegraded, ImpactPartialOutage:
+			indicator = "minor"
+		}
+	}
+
+	return indicator
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when the latest incident or maintenance started; it is zero when there are none
+func (r Response) UpdatedAt() time.Time {
+	return r.updatedAt
+}
+
+// URL returns the status page
+func (r Response) URL() string {
+	return r.Page.URL
+}
+
+// Summary lists the titles of the incidents and maintenances in progress
+func (r Response) Summary() []string {
+	var lines []string
+	for _, i := range r.ActiveIncidents {
+		lines = append(lines, "Incident: "+i.Name)
+	}
+	for _, m := range r.ActiveMaintenances {
+		lines = append(lines, "Maintenance: "+m.Name)
+	}
+
+	return lines
+}
+
+// latest returns when the most recent incident or maintenance started
+func (r Response) latest() time.Time {
+	var latest time.Time
+	for _, i := range r.ActiveIncidents {
+		if i.Started.After(latest) {
+			latest = i.Started
+		}
+	}
+	for _, m := range r.ActiveMaintenances {
+		if m.Start.After(latest) {
+			latest = m.Start
+		}
+	}
+
+	return latest
+}
+
+// ClientReader implements the Reader interface for Instatus pages, e.g. https://status.example.com/summary.json
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Response
+
+	err = g.GetJSON(cr.PageURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Page.Status == "" {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, cr.PageURL+" did not report a status")
+	}
+
+	resp.name = cr.ServiceName
+	if resp.Page.URL == "" {
+		resp.Page.URL = cr.PageURL
+	}
+
+	resp.updatedAt = resp.latest()
+
+	return resp, nil
+}
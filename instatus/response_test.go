@@ -0,0 +1,117 @@
+package instatus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Response_Indicator(t *testing.T) {
+	tests := map[string]struct {
+		resp     Response
+		expected string
+	}{
+		"base path- up": {
+			resp:     Response{Page: Page{Status: PageUp}},
+			expected: "none",
+		},
+		"base path- has issues without incidents": {
+			resp:     Response{Page: Page{Status: PageHasIssues}},
+			expected: "minor",
+		},
+		"base path- under maintenance": {
+			resp:     Response{Page: Page{Status: PageUnderMaintenance}},
+			expected: status.IndicatorMaintenance,
+		},
+		"base path- degraded performance": {
+			resp:     Response{Page: Page{Status: PageHasIssues}, ActiveIncidents: []Incident{{Impact: ImpactDegraded}}},
+			expected: "minor",
+		},
+		"base path- major outage outranks maintenance": {
+			resp:     Response{Page: Page{Status: PageUnderMaintenance}, ActiveIncidents: []Incident{{Impact: ImpactPartialOutage}, {Impact: ImpactMajorOutage}}},
+			expected: "major",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.resp.Indicator())
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- partial outage during maintenance": {
+			reader: ClientReader{ServiceName: "Acme", PageURL: "https://status.acme.dev/summary.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, "Acme", details.Name())
+				require.Equal(t, "https://status.acme.dev", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 13, 41, 0, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"Incident: Delayed webhook deliveries",
+					"Maintenance: Database upgrade",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- up": {
+			reader: ClientReader{ServiceName: "Widgets", PageURL: "https://status.widgets.io/summary.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.True(t, details.UpdatedAt().IsZero())
+				require.Empty(t, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- major outage": {
+			reader: ClientReader{ServiceName: "Gadgets", PageURL: "https://status.gadgets.app/summary.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, []string{"Incident: API unavailable"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://status.unknown.dev/summary.json"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://status.unknown.dev/summary.json"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Acme", PageURL: "https://status.acme.dev/summary.json"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"page":{}}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
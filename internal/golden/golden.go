@@ -0,0 +1,32 @@
+// Package golden compares test output against the golden files kept in each package's testdata/golden directory
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Require compares the output against testdata/golden/<test name>.golden, rewriting the file when run with -update
+func Require(t *testing.T, actual []byte) {
+	t.Helper()
+
+	filename := filepath.Join("testdata", "golden", unsafeChars.ReplaceAllString(t.Name(), "_")+".golden")
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, actual, 0644))
+	}
+
+	expected, err := os.ReadFile(filename)
+	require.NoError(t, err, "missing golden file; run the tests with -update to create it")
+	require.Equal(t, string(expected), string(actual))
+}
@@ -0,0 +1,15 @@
+// Package mapkeys lists the keys of string keyed maps in a stable order
+package mapkeys
+
+import "sort"
+
+// Sorted returns the keys of the map in ascending order
+func Sorted[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
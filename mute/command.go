@@ -0,0 +1,47 @@
+package mute
+
+import (
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ErrorInvalidCommand is returned when the plugin is run with arguments we do not understand
+const ErrorInvalidCommand = "INVALID_MUTE_COMMAND"
+
+// Run updates the state from the arguments xbar passes when one of the dropdown actions is clicked
+func (s State) Run(args []string, now time.Time) glitch.DataError {
+	if len(args) < 2 {
+		return glitch.NewDataError(nil, ErrorInvalidCommand, "a command and service name are required")
+	}
+
+	command, name := args[0], args[1]
+
+	switch {
+	case command == status.CommandSnooze && len(args) == 3:
+		if args[2] == status.SnoozeUntilResolved {
+			s.SnoozeUntilResolved(name)
+			return nil
+		}
+
+		d, pErr := time.ParseDuration(args[2])
+		if pErr != nil {
+			return glitch.NewDataError(pErr, ErrorInvalidCommand, "invalid snooze duration "+args[2])
+		}
+		s.Snooze(name, d, now)
+	case command == status.CommandAcknowledge && len(args) == 4:
+		updatedAt, pErr := time.Parse(status.IncidentTimeLayout, args[3])
+		if pErr != nil {
+			return glitch.NewDataError(pErr, ErrorInvalidCommand, "invalid incident time "+args[3])
+		}
+		s.Acknowledge(name, args[2], updatedAt)
+	case command == status.CommandUnmute && len(args) == 2:
+		s.Unmute(name)
+	default:
+		return glitch.NewDataError(nil, ErrorInvalidCommand, "unknown command "+command)
+	}
+
+	return nil
+}
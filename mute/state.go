@@ -0,0 +1,132 @@
+// Package mute handles snoozing and acknowledging services so they stop affecting the overall status
+package mute
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// Error codes
+const (
+	ErrorUnableToParseState = "UNABLE_TO_PARSE_MUTE_STATE"
+	ErrorUnableToWriteState = "UNABLE_TO_WRITE_MUTE_STATE"
+)
+
+// Entry holds why and for how long a service is muted
+type Entry struct {
+	// Until is when a snooze ends
+	Until time.Time `json:"until,omitempty"`
+	// UntilResolved snoozes the service until it reports no issues
+	UntilResolved bool `json:"until_resolved,omitempty"`
+	// Indicator and UpdatedAt identify the acknowledged incident; the acknowledgement ends when either changes
+	Indicator string    `json:"indicator,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// State is a mapping of services, by status.Overview.MuteKey, to their mute entry
+type State map[string]Entry
+
+// Load reads the mute state from disk; a missing file means nothing is muted
+func Load(r configuration.Reader, filename string) (State, glitch.DataError) {
+	s := State{}
+
+	data, rErr := r.ReadFile(filename)
+	if rErr != nil {
+		return s, nil
+	}
+
+	uErr := json.Unmarshal(data, &s)
+	if uErr != nil {
+		return State{}, glitch.NewDataError(uErr, ErrorUnableToParseState, "error parsing What's Up mute state")
+	}
+
+	return s, nil
+}
+
+// Save writes the mute state to disk
+func (s State) Save(w configuration.Writer, filename string) glitch.DataError {
+	data, mErr := json.MarshalIndent(s, "", "  ")
+	if mErr != nil {
+		return glitch.NewDataError(mErr, ErrorUnableToWriteState, "unable to encode What's Up mute state")
+	}
+
+	wErr := w.WriteFile(filename, data, 0644)
+	if wErr != nil {
+		return glitch.NewDataError(wErr, ErrorUnableToWriteState, "unable to write What's Up mute state")
+	}
+
+	return nil
+}
+
+// Snooze mutes a service for the given duration
+func (s State) Snooze(name string, d time.Duration, now time.Time) {
+	s[name] = Entry{Until: now.Add(d)}
+}
+
+// SnoozeUntilResolved mutes a service until it reports no issues
+func (s State) SnoozeUntilResolved(name string) {
+	s[name] = Entry{UntilResolved: true}
+}
+
+// Acknowledge mutes a service until its indicator or incident changes
+func (s State) Acknowledge(name, indicator string, updatedAt time.Time) {
+	s[name] = Entry{Indicator: indicator, UpdatedAt: updatedAt}
+}
+
+// Unmute removes any snooze or acknowledgement for a service
+func (s State) Unmute(name string) {
+	delete(s, name)
+}
+
+// isMuted reports whether the entry still applies to the service's current details
+func (e Entry) isMuted(d whatsupstatus.Details, now time.Time) bool {
+	switch {
+	case e.UntilResolved:
+		return d.Indicator() != "none"
+	case !e.Until.IsZero():
+		return now.Before(e.Until)
+	default:
+		return e.Indicator == d.Indicator() && e.UpdatedAt.Equal(d.UpdatedAt())
+	}
+}
+
+// Apply moves muted services out of the severity lists of the overview and recalculates its overall status. Entries
+// that no longer apply -- the snooze expired, the service recovered, or the acknowledged incident changed -- are
+// removed from the state.
+func (s State) Apply(o status.Overview, now time.Time) status.Overview {
+	list := status.List{}
+	for severity, details := range o.List {
+		for _, d := range details {
+			key := o.MuteKey(d)
+			e, ok := s[key]
+			if ok && e.isMuted(d, now) {
+				list[status.SeverityMuted] = append(list[status.SeverityMuted], d)
+				continue
+			}
+
+			if ok {
+				delete(s, key)
+			}
+
+			list[severity] = append(list[severity], d)
+		}
+	}
+
+	// Expired snoozes are removed even for services we could not read
+	for name, e := range s {
+		if !e.Until.IsZero() && !now.Before(e.Until) {
+			delete(s, name)
+		}
+	}
+
+	o.List = list
+	o.OverallStatus = list.OverallStatus()
+
+	return o
+}
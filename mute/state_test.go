@@ -0,0 +1,222 @@
+package mute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_State_Apply(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	incidentAt := now.Add(-2 * time.Hour)
+
+	circleCI := testResponse{name: "CircleCI", indicator: "major", updatedAt: incidentAt}
+	slack := testResponse{name: "Slack", indicator: "minor", updatedAt: incidentAt}
+	sentry := testResponse{name: "Sentry", indicator: "none", updatedAt: incidentAt}
+
+	overview := status.Overview{
+		OverallStatus: "major",
+		List: status.List{
+			"major": {circleCI},
+			"minor": {slack},
+			"none":  {sentry},
+		},
+	}
+
+	tests := map[string]struct {
+		state            State
+		expectedOverview status.Overview
+		expectedState    State
+	}{
+		"base path- nothing muted": {
+			state:            State{},
+			expectedOverview: overview,
+			expectedState:    State{},
+		},
+		"base path- snoozed service no longer counts": {
+			state: State{"CircleCI": {Until: now.Add(time.Hour)}},
+			expectedOverview: status.Overview{
+				OverallStatus: "minor",
+				List: status.List{
+					"minor":              {slack},
+					"none":               {sentry},
+					status.SeverityMuted: {circleCI},
+				},
+			},
+			expectedState: State{"CircleCI": {Until: now.Add(time.Hour)}},
+		},
+		"base path- expired snooze is removed": {
+			state:            State{"CircleCI": {Until: now.Add(-time.Minute)}, "GitHub": {Until: now.Add(-time.Minute)}},
+			expectedOverview: overview,
+			expectedState:    State{},
+		},
+		"base path- acknowledged incidents stay muted until they change": {
+			state: State{
+				"CircleCI": {Indicator: "major", UpdatedAt: incidentAt},
+				"Slack":    {Indicator: "minor", UpdatedAt: incidentAt.Add(-time.Hour)},
+			},
+			expectedOverview: status.Overview{
+				OverallStatus: "minor",
+				List: status.List{
+					"minor":              {slack},
+					"none":               {sentry},
+					status.SeverityMuted: {circleCI},
+				},
+			},
+			expectedState: State{"CircleCI": {Indicator: "major", UpdatedAt: incidentAt}},
+		},
+		"base path- snoozed until resolved": {
+			state: State{"Slack": {UntilResolved: true}, "Sentry": {UntilResolved: true}},
+			expectedOverview: status.Overview{
+				OverallStatus: "major",
+				List: status.List{
+					"major":              {circleCI},
+					"none":               {sentry},
+					status.SeverityMuted: {slack},
+				},
+			},
+			expectedState: State{"Slack": {UntilResolved: true}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := tc.state.Apply(overview, now)
+			require.Equal(t, tc.expectedOverview, o)
+			require.Equal(t, tc.expectedState, tc.state)
+		})
+	}
+}
+
+func TestUnit_State_Apply_MuteKey(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	slack := testResponse{name: "Slack", indicator: "minor", updatedAt: now}
+	queue := status.Part{PartName: "Internal / queue", PartIndicator: "major", PartUpdatedAt: now}
+	api := status.Part{PartName: "Internal / api", PartIndicator: "major", PartUpdatedAt: now}
+
+	// Slack reports its own name; the site is configured as Team chat
+	overview := status.Overview{
+		List:  status.List{"minor": {slack}, "major": {queue, api}},
+		Sites: map[string]string{"Slack": "Team chat", "Internal / queue": "Internal", "Internal / api": "Internal"},
+	}
+
+	state := State{"Team chat": {UntilResolved: true}, "Internal / queue": {UntilResolved: true}, "Slack": {UntilResolved: true}}
+	o := state.Apply(overview, now)
+
+	require.Equal(t, []whatsupstatus.Details{api}, o.List["major"])
+	require.Empty(t, o.List["minor"])
+	require.ElementsMatch(t, []whatsupstatus.Details{slack, queue}, o.List[status.SeverityMuted])
+	require.Equal(t, "major", o.OverallStatus)
+
+	require.Equal(t, State{"Team chat": {UntilResolved: true}, "Internal / queue": {UntilResolved: true}, "Slack": {UntilResolved: true}}, state)
+}
+
+func TestUnit_State_Run(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		state         State
+		args          []string
+		expectedState State
+		expectedErr   glitch.DataError
+	}{
+		"base path- snooze for a duration": {
+			state:         State{},
+			args:          []string{status.CommandSnooze, "Slack", "4h"},
+			expectedState: State{"Slack": {Until: now.Add(4 * time.Hour)}},
+		},
+		"base path- snooze until resolved": {
+			state:         State{},
+			args:          []string{status.CommandSnooze, "Slack", status.SnoozeUntilResolved},
+			expectedState: State{"Slack": {UntilResolved: true}},
+		},
+		"base path- acknowledge": {
+			state:         State{},
+			args:          []string{status.CommandAcknowledge, "Slack", "minor", "2024-03-01T10:00:29.531-08:00"},
+			expectedState: State{"Slack": {Indicator: "minor", UpdatedAt: time.Date(2024, time.March, 1, 10, 0, 29, 531000000, time.FixedZone("", -8*60*60))}},
+		},
+		"exceptional path- invalid incident time": {
+			state:         State{},
+			args:          []string{status.CommandAcknowledge, "Slack", "minor", "yesterday"},
+			expectedState: State{},
+			expectedErr:   glitch.NewDataError(nil, ErrorInvalidCommand, "invalid incident time yesterday"),
+		},
+		"base path- unmute": {
+			state:         State{"Slack": {UntilResolved: true}},
+			args:          []string{status.CommandUnmute, "Slack"},
+			expectedState: State{},
+		},
+		"exceptional path- invalid duration": {
+			state:         State{},
+			args:          []string{status.CommandSnooze, "Slack", "forever"},
+			expectedState: State{},
+			expectedErr:   glitch.NewDataError(nil, ErrorInvalidCommand, "invalid snooze duration forever"),
+		},
+		"exceptional path- unknown command": {
+			state:         State{},
+			args:          []string{"mute", "Slack"},
+			expectedState: State{},
+			expectedErr:   glitch.NewDataError(nil, ErrorInvalidCommand, "unknown command mute"),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.state.Run(tc.args, now)
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErr.Code(), err.Code())
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectedState, tc.state)
+		})
+	}
+}
+
+func TestUnit_State_Acknowledge_FractionalSeconds(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 20, 0, 0, 0, time.UTC)
+
+	// statuspage.io reports times such as 2024-03-01T10:00:29.531-08:00
+	updatedAt, err := time.Parse(time.RFC3339, "2024-03-01T10:00:29.531-08:00")
+	require.NoError(t, err)
+	slack := testResponse{name: "Slack", indicator: "minor", updatedAt: updatedAt}
+
+	// The incident time travels through the Acknowledge action as text
+	s := State{}
+	require.NoError(t, s.Run([]string{status.CommandAcknowledge, "Slack", "minor", updatedAt.UTC().Format(status.IncidentTimeLayout)}, now))
+
+	o := s.Apply(status.Overview{List: status.List{"minor": {slack}}}, now)
+	require.Equal(t, status.List{status.SeverityMuted: {slack}}, o.List)
+	require.Contains(t, s, "Slack")
+
+	// The next run still finds the acknowledgement
+	o = s.Apply(status.Overview{List: status.List{"minor": {slack}}}, now.Add(time.Hour))
+	require.Equal(t, status.List{status.SeverityMuted: {slack}}, o.List)
+}
+
+type testResponse struct {
+	name      string
+	indicator string
+	updatedAt time.Time
+}
+
+func (tr testResponse) Indicator() string {
+	return tr.indicator
+}
+
+func (tr testResponse) Name() string {
+	return tr.name
+}
+
+func (tr testResponse) UpdatedAt() time.Time {
+	return tr.updatedAt
+}
+
+func (tr testResponse) URL() string {
+	return "https://test.service/"
+}
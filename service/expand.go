@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// Error codes
+const (
+	ErrorUnresolvedVariable = "UNRESOLVED_VARIABLE"
+	ErrorInvalidTemplate    = "INVALID_TEMPLATE"
+)
+
+// SiteKeyForEach turns a site into a template repeated for every combination of the values it lists, e.g.
+// {"region": ["us", "eu", "ap"]} adds one site per region with ${region} replaced in its name, URL, and headers
+const SiteKeyForEach = "for_each"
+
+// Lookup returns the value of a variable and whether it is set, e.g. os.LookupEnv
+type Lookup func(key string) (string, bool)
+
+// noVariables is the lookup for sites that may not read the environment; only defaults apply
+func noVariables(string) (string, bool) {
+	return "", false
+}
+
+// expandSites replaces ${VAR} and ${VAR:-default} in the name, URL, and header values of every site and repeats the
+// sites with a for_each entry. Sites listed in untrusted only see their for_each variables, so a remote configuration
+// cannot copy the environment into the URLs it points at. It returns the names each site was expanded into.
+func expandSites(doc map[string]interface{}, lookup Lookup, untrusted map[string]bool) (map[string][]string, glitch.DataError) {
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sites := map[string]interface{}{}
+	renamed := map[string][]string{}
+
+	for _, name := range names {
+		site, isObject := doc[name].(map[string]interface{})
+		if !isObject {
+			// Left for the configuration parser to report on
+			sites[name] = doc[name]
+			renamed[name] = []string{name}
+			continue
+		}
+
+		combinations, err := forEach(name, site[SiteKeyForEach])
+		if err != nil {
+			return nil, err
+		}
+
+		base := lookup
+		if untrusted[name] {
+			base = noVariables
+		}
+
+		for _, vars := range combinations {
+			l := func(key string) (string, bool) {
+				if v, ok := vars[key]; ok {
+					return v, true
+				}
+				return base(key)
+			}
+
+			expandedName, eErr := expandField(name, "its name", name, l)
+			if eErr != nil {
+				return nil, eErr
+			}
+
+			expanded, sErr := expandSite(expandedName, site, l)
+			if sErr != nil {
+				return nil, sErr
+			}
+
+			if _, exists := sites[expandedName]; exists {
+				return nil, glitch.NewDataError(nil, ErrorInvalidTemplate, "more than one site is named "+expandedName+
+					"; use the for_each variables in the site name")
+			}
+
+			sites[expandedName] = expanded
+			renamed[name] = append(renamed[name], expandedName)
+		}
+	}
+
+	for _, name := range names {
+		delete(doc, name)
+	}
+	for name, site := range sites {
+		doc[name] = site
+	}
+
+	return renamed, nil
+}
+
+// forEach returns every combination of the template variables, in the order listed and sorted by variable name; a
+// site without a for_each entry has a single combination with no variables
+func forEach(name string, v interface{}) ([]map[string]string, glitch.DataError) {
+	if v == nil {
+		return []map[string]string{{}}, nil
+	}
+
+	vars, isObject := v.(map[string]interface{})
+	if !isObject || len(vars) == 0 {
+		return nil, glitch.NewDataError(nil, ErrorInvalidTemplate, "the for_each entry of "+name+" must map variable names to lists of values")
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	combinations := []map[string]string{{}}
+	for _, k := range keys {
+		values, isList := vars[k].([]interface{})
+		if !isList || len(values) == 0 {
+			return nil, glitch.NewDataError(nil, ErrorInvalidTemplate, "the for_each variable "+k+" of "+name+" must be a list of values")
+		}
+
+		var next []map[string]string
+		for _, c := range combinations {
+			for _, value := range values {
+				s, isString := value.(string)
+				if !isString {
+					return nil, glitch.NewDataError(nil, ErrorInvalidTemplate, "the for_each variable "+k+" of "+name+" must only list strings")
+				}
+
+				combination := map[string]string{k: s}
+				for ck, cv := range c {
+					combination[ck] = cv
+				}
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations, nil
+}
+
+// expandSite returns a copy of the site with its URL and header values expanded
+func expandSite(name string, site map[string]interface{}, lookup Lookup) (map[string]interface{}, glitch.DataError) {
+	expanded := map[string]interface{}{}
+	for k, v := range site {
+		if k != SiteKeyForEach {
+			expanded[k] = v
+		}
+	}
+
+	if u, isString := expanded["url"].(string); isString {
+		v, err := expandField(name, "its url", u, lookup)
+		if err != nil {
+			return nil, err
+		}
+		expanded["url"] = v
+	}
+
+	headers, isObject := expanded["headers"].(map[string]interface{})
+	if !isObject {
+		return expanded, nil
+	}
+
+	expandedHeaders := map[string]interface{}{}
+	for k, h := range headers {
+		field := "its " + k + " header"
+
+		switch header := h.(type) {
+		case string:
+			v, err := expandField(name, field, header, lookup)
+			if err != nil {
+				return nil, err
+			}
+			expandedHeaders[k] = v
+		default:
+			// Secrets are left alone; commands in particular may rely on the shell expanding variables
+			expandedHeaders[k] = h
+		}
+	}
+	expanded["headers"] = expandedHeaders
+
+	return expanded, nil
+}
+
+// expandField expands a value of the site, describing the variable and where it was used when it cannot be resolved
+func expandField(site, field, s string, lookup Lookup) (string, glitch.DataError) {
+	v, err := expand(s, lookup)
+	if err != nil {
+		return "", glitch.NewDataError(err, ErrorUnresolvedVariable, fmt.Sprintf("site %s: %s in %s", site, err.Error(), field))
+	}
+
+	return v, nil
+}
+
+// expand replaces ${VAR} with the value of VAR, and ${VAR:-default} with the value of VAR or the default when VAR is
+// unset or empty; $$ is a literal $
+func expand(s string, lookup Lookup) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", errors.New("${ is missing its closing }")
+		}
+
+		expr := s[i+2 : i+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if name == "" {
+			return "", errors.New("${} is missing a variable name")
+		}
+
+		v, ok := lookup(name)
+		switch {
+		case hasDefault && v == "":
+			v = def
+		case !ok:
+			return "", errors.New("${" + name + "} is not set")
+		}
+
+		b.WriteString(v)
+		i += end
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Expand(t *testing.T) {
+	lookup := mapLookup{"ENV": "staging", "EMPTY": ""}
+
+	tests := map[string]struct {
+		value       string
+		expected    string
+		expectedErr string
+	}{
+		"base path- no variables": {
+			value:    "https://status.example.com",
+			expected: "https://status.example.com",
+		},
+		"base path- variable": {
+			value:    "https://${ENV}.example.com/health",
+			expected: "https://staging.example.com/health",
+		},
+		"base path- default used when unset": {
+			value:    "https://${REGION:-us}.example.com",
+			expected: "https://us.example.com",
+		},
+		"base path- default used when empty": {
+			value:    "${EMPTY:-fallback}",
+			expected: "fallback",
+		},
+		"base path- default ignored when set": {
+			value:    "${ENV:-prod}",
+			expected: "staging",
+		},
+		"base path- empty variable": {
+			value:    "a${EMPTY}b",
+			expected: "ab",
+		},
+		"base path- literal dollars": {
+			value:    "$$5 or $5",
+			expected: "$5 or $5",
+		},
+		"exceptional path- unset variable": {
+			value:       "https://${MISSING}.example.com",
+			expectedErr: "${MISSING} is not set",
+		},
+		"exceptional path- unclosed variable": {
+			value:       "https://${ENV.example.com",
+			expectedErr: "${ is missing its closing }",
+		},
+		"exceptional path- missing name": {
+			value:       "${:-x}",
+			expectedErr: "${} is missing a variable name",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := expand(tc.value, lookup.Lookup)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestUnit_ExpandSites(t *testing.T) {
+	lookup := mapLookup{"ENV": "staging", "TOKEN": "abc"}
+
+	tests := map[string]struct {
+		doc             map[string]interface{}
+		expectedDoc     map[string]interface{}
+		expectedRenamed map[string][]string
+		expectedErrCode string
+	}{
+		"base path- name, URL, and headers are expanded; secrets are not": {
+			doc: map[string]interface{}{
+				"API (${ENV})": map[string]interface{}{
+					"url":  "https://${ENV}.example.com/status.json",
+					"type": "statuspage.io",
+					"headers": map[string]interface{}{
+						"X-Env":    "${ENV}",
+						"X-Secret": map[string]interface{}{"command": "echo ${HOME}"},
+					},
+				},
+			},
+			expectedDoc: map[string]interface{}{
+				"API (staging)": map[string]interface{}{
+					"url":  "https://staging.example.com/status.json",
+					"type": "statuspage.io",
+					"headers": map[string]interface{}{
+						"X-Env":    "staging",
+						"X-Secret": map[string]interface{}{"command": "echo ${HOME}"},
+					},
+				},
+			},
+			expectedRenamed: map[string][]string{"API (${ENV})": {"API (staging)"}},
+		},
+		"base path- site repeated per region": {
+			doc: map[string]interface{}{
+				"API ${region}": map[string]interface{}{
+					"url":      "https://${region}.${ENV}.example.com",
+					"type":     "statuspage.io",
+					"for_each": map[string]interface{}{"region": []interface{}{"us", "eu", "ap"}},
+				},
+			},
+			expectedDoc: map[string]interface{}{
+				"API us": map[string]interface{}{"url": "https://us.staging.example.com", "type": "statuspage.io"},
+				"API eu": map[string]interface{}{"url": "https://eu.staging.example.com", "type": "statuspage.io"},
+				"API ap": map[string]interface{}{"url": "https://ap.staging.example.com", "type": "statuspage.io"},
+			},
+			expectedRenamed: map[string][]string{"API ${region}": {"API us", "API eu", "API ap"}},
+		},
+		"base path- every combination of several variables": {
+			doc: map[string]interface{}{
+				"${app} ${region}": map[string]interface{}{
+					"url":      "https://${app}.${region}.example.com",
+					"for_each": map[string]interface{}{"region": []interface{}{"us", "eu"}, "app": []interface{}{"api", "web"}},
+				},
+			},
+			expectedDoc: map[string]interface{}{
+				"api us": map[string]interface{}{"url": "https://api.us.example.com"},
+				"api eu": map[string]interface{}{"url": "https://api.eu.example.com"},
+				"web us": map[string]interface{}{"url": "https://web.us.example.com"},
+				"web eu": map[string]interface{}{"url": "https://web.eu.example.com"},
+			},
+			expectedRenamed: map[string][]string{"${app} ${region}": {"api us", "api eu", "web us", "web eu"}},
+		},
+		"exceptional path- unresolved variable in a URL": {
+			doc: map[string]interface{}{
+				"API": map[string]interface{}{"url": "https://${MISSING}.example.com"},
+			},
+			expectedErrCode: ErrorUnresolvedVariable,
+		},
+		"exceptional path- unresolved variable in a header": {
+			doc: map[string]interface{}{
+				"API": map[string]interface{}{"url": "https://example.com", "headers": map[string]interface{}{"X-Key": "${MISSING}"}},
+			},
+			expectedErrCode: ErrorUnresolvedVariable,
+		},
+		"exceptional path- template name does not use its variables": {
+			doc: map[string]interface{}{
+				"API": map[string]interface{}{"url": "https://${region}.example.com", "for_each": map[string]interface{}{"region": []interface{}{"us", "eu"}}},
+			},
+			expectedErrCode: ErrorInvalidTemplate,
+		},
+		"exceptional path- for_each is not a list of strings": {
+			doc: map[string]interface{}{
+				"API ${region}": map[string]interface{}{"for_each": map[string]interface{}{"region": "us"}},
+			},
+			expectedErrCode: ErrorInvalidTemplate,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			renamed, err := expandSites(tc.doc, lookup.Lookup, nil)
+			if tc.expectedErrCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErrCode, err.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDoc, tc.doc)
+			require.Equal(t, tc.expectedRenamed, renamed)
+		})
+	}
+}
+
+func TestUnit_LoadConfig_Variables(t *testing.T) {
+	t.Setenv("WHATSUP_TEST_ENV", "staging")
+
+	c, err := LoadConfig(mapReader{
+		"/cfg/config.json": `{"Health ${region}":{"url":"https://${region}.${WHATSUP_TEST_ENV}.example.com/health","type":"statuspage.io","for_each":{"region":["us","eu"]}}}`,
+	}, nil, "/cfg/config.json")
+	require.NoError(t, err)
+	require.Len(t, c.Sites, 2)
+	us, eu := c.Sites["Health us"], c.Sites["Health eu"]
+	require.Equal(t, "https://us.staging.example.com/health", us.URL.String())
+	require.Equal(t, "https://eu.staging.example.com/health", eu.URL.String())
+
+	_, err = LoadConfig(mapReader{
+		"/cfg/config.json": `{"Health":{"url":"https://${WHATSUP_TEST_UNSET}.example.com/health","type":"statuspage.io"}}`,
+	}, nil, "/cfg/config.json")
+	require.Error(t, err)
+	require.Equal(t, ErrorUnresolvedVariable, err.Code())
+	require.Contains(t, err.Error(), "site Health: ${WHATSUP_TEST_UNSET} is not set in its url")
+}
+
+// mapLookup serves variables from memory
+type mapLookup map[string]string
+
+func (m mapLookup) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
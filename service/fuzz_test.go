@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/betterstack"
+	"github.com/sprak3000/xbar-whats-up/cachet"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/gatus"
+	"github.com/sprak3000/xbar-whats-up/googlecloud"
+	"github.com/sprak3000/xbar-whats-up/instatus"
+	"github.com/sprak3000/xbar-whats-up/slack"
+	"github.com/sprak3000/xbar-whats-up/status"
+	"github.com/sprak3000/xbar-whats-up/statusio"
+	"github.com/sprak3000/xbar-whats-up/statuspageio"
+	"github.com/sprak3000/xbar-whats-up/uptimekuma"
+)
+
+func FuzzSite_UnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"url":"https://status.codeclimate.com/api/v2/status.json","type":"statuspage.io"}`))
+	f.Add([]byte(`{"url":":","type":"statuspage.io"}`))
+	f.Add([]byte(`{"url":null,"type":null,"group":7}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(_ *testing.T, data []byte) {
+		var s Site
+		if json.Unmarshal(data, &s) == nil {
+			_ = s.URL.String()
+		}
+	})
+}
+
+func FuzzLoadConfig(f *testing.F) {
+	f.Add([]byte(`{"CodeClimate":{"url":"https://status.codeclimate.com/api/v2/status.json","type":"statuspage.io"}}`))
+	f.Add([]byte(`{"display":{"icons":{"major":"🔥","none":{"image":"aW1hZ2U="}},"colors":{"date":"37"},"dark":{"size":14}},"Slack":{"url":"https://status.slack.com/api/v2.0.0/current","type":"slack"}}`))
+	f.Add([]byte(`{"display":null,"Slack":null}`))
+	f.Add([]byte("{\n}"))
+	f.Add([]byte(`{"version":2,"settings":{"display":{"title":"counts"}},"sites":{"Slack":{"url":"https://status.slack.com/api/v2.0.0/current","type":"slack"}}}`))
+
+	f.Fuzz(func(_ *testing.T, data []byte) {
+		config, err := LoadConfig(fuzzReader{data: data}, fileWriterSuccess{}, "fuzz.json")
+		if err != nil {
+			return
+		}
+
+		_ = config.Display.Resolve(true)
+		for _, s := range config.Sites {
+			_ = s.URL.String()
+		}
+	})
+}
+
+// FuzzGetOverview feeds a remote payload through every provider and renders the result
+func FuzzGetOverview(f *testing.F) {
+	f.Add([]byte(`{"page":{"name":"CircleCI"},"status":{"indicator":"major","description":"Partial System Outage"}}`), 200)
+	f.Add([]byte(`{"status":"active","active_incidents":[]}`), 200)
+	f.Add([]byte(`{"result":{"status_overall":{"status":"Degraded Performance","status_code":300},"status":[{"name":"API","status":"Degraded Performance","status_code":300,"containers":[{"name":"US East","status":"Degraded Performance","status_code":300}]}]}}`), 200)
+	f.Add([]byte(`{"page":{"status":"HASISSUES"},"activeIncidents":[{"name":"Delayed webhooks","impact":"MAJOROUTAGE"}]}`), 200)
+	f.Add([]byte(`{"data":{"attributes":{"aggregate_state":"degraded"}},"included":[{"type":"status_report","attributes":{"title":"Slow API"}}]}`), 200)
+	f.Add([]byte(`{"data":[{"id":1,"name":"API","status":4,"status_name":"Major Outage","enabled":true,"updated_at":"2024-03-04 14:30:00"}]}`), 200)
+	f.Add([]byte(`[{"external_desc":"Cloud Run deployments are failing","severity":"high","affected_products":[{"title":"Cloud Run"}],"currently_affected_locations":[{"id":"us-central1"}]}]`), 200)
+	f.Add([]byte(`{"config":{"slug":"internal"},"publicGroupList":[{"monitorList":[{"id":1,"name":"API"}]}],"heartbeatList":{"1":[{"status":0,"time":"2024-03-04 14:58:45"}]}}`), 200)
+	f.Add([]byte(`[{"name":"queue","group":"core","results":[{"success":false,"timestamp":"2024-03-04T14:59:30Z"}]}]`), 200)
+	f.Add([]byte(`null`), 200)
+	f.Add([]byte(`Internal Server Error`), 500)
+
+	f.Fuzz(func(_ *testing.T, body []byte, statusCode int) {
+		if statusCode < 100 || statusCode > 999 {
+			statusCode = http.StatusOK
+		}
+
+		sites := Sites{}
+		for _, serviceType := range []string{statuspageio.ServiceType, slack.ServiceType, statusio.ServiceType, instatus.ServiceType, betterstack.ServiceType, cachet.ServiceType, googlecloud.ServiceType, uptimekuma.ServiceType, gatus.ServiceType} {
+			var s Site
+			_ = json.Unmarshal([]byte(`{"url":"https://status.example.com/status/`+serviceType+`","type":"`+serviceType+`"}`), &s)
+			sites[serviceType] = s
+
+			s.Monitors = MonitorsExpand
+			sites[serviceType+" expanded"] = s
+		}
+
+		sites.GetOverview(fetch.NewStaticClient(statusCode, "application/json", body)).Display(io.Discard)
+	})
+}
+
+// FuzzReadSite feeds a payload through every provider that works out its own indicator; whatever the payload, the
+// provider either fails or reports, for itself and each of its monitors, an indicator the menu knows how to show.
+// statuspage.io and Slack pass on the indicator their page reports and have fuzz targets of their own.
+func FuzzReadSite(f *testing.F) {
+	providers := []struct {
+		site  Site
+		seeds []string
+	}{
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "api.status.io", Path: "/1.0/status/fuzz"}, Type: statusio.ServiceType},
+			seeds: []string{`{"result":{"status_overall":{"updated":"2024-03-04T14:52:11.000Z","status":"Partial Service Disruption","status_code":400},"status":[{"name":"API","status":"Partial Service Disruption","status_code":400,"containers":[{"name":"US East","status":"Partial Service Disruption","status_code":400}]}],"incidents":[{"name":"Elevated API error rates"}]}}`, `{"result":{"status_overall":null,"status":null}}`},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "status.fuzz.dev", Path: "/summary.json"}, Type: instatus.ServiceType},
+			seeds: []string{`{"page":{"name":"Acme","url":"https://status.acme.dev","status":"HASISSUES"},"activeIncidents":[{"name":"Delayed webhook deliveries","started":"2024-03-04T13:41:00.000Z","impact":"PARTIALOUTAGE"}],"activeMaintenances":[{"name":"Database upgrade","start":"2024-03-04T12:00:00.000Z"}]}`, `{"page":null,"activeIncidents":null}`},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "status.fuzz.dev", Path: "/index.json"}, Type: betterstack.ServiceType},
+			seeds: []string{`{"data":{"attributes":{"aggregate_state":"degraded","updated_at":"2024-03-04T10:15:42.000Z"}},"included":[{"type":"status_page_resource","attributes":{"public_name":"API","status":"downtime"}},{"type":"status_report","attributes":{"title":"Slow API responses","starts_at":"2024-03-04T14:20:00.000Z","ends_at":null}}]}`, `{"data":null,"included":[null]}`},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "status.example.com"}, Type: cachet.ServiceType},
+			seeds: []string{`{"meta":{"pagination":{"links":{"next_page":null}}},"data":[{"id":1,"name":"API","status":3,"status_name":"Partial Outage","group_id":1,"enabled":true,"updated_at":"2024-03-04 14:30:00","component_id":1,"human_status":"Identified"}]}`, `{"meta":{"pagination":{"links":{"next_page":"https://status.example.com/api/v1/components?page=2"}}},"data":[{"id":1,"enabled":true}]}`, `{"data":null}`},
+		},
+		{
+			site: Site{URL: url.URL{Scheme: "https", Host: "status.example.com"}, Type: cachet.ServiceType, ComponentGroups: []string{"1"}},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "status.cloud.google.com", Path: "/incidents.json"}, Type: googlecloud.ServiceType},
+			seeds: []string{`[{"id":"pYx3mK9Qw1","modified":"2024-03-04T14:55:03+00:00","external_desc":"Cloud Run deployments are failing\nInvestigating","severity":"high","affected_products":[{"id":"9D7d2iNBQWN24zc1VamE","title":"Cloud Run"}],"currently_affected_locations":[{"id":"us-central1","title":"Iowa (us-central1)"}]}]`, `[{"end":null,"most_recent_update":null,"affected_products":null}]`, `[]`},
+		},
+		{
+			site: Site{URL: url.URL{Scheme: "https", Host: "status.cloud.google.com", Path: "/incidents.json"}, Type: googlecloud.ServiceType, Products: []string{"Cloud Run"}, Regions: []string{"us-central1"}},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "kuma.example.com", Path: "/status/fuzz"}, Type: uptimekuma.ServiceType, Monitors: MonitorsExpand},
+			seeds: []string{`{"config":{"slug":"internal"},"incident":{"title":"VPN drops","style":"danger"},"publicGroupList":[{"monitorList":[{"id":1,"name":"API"}]}],"heartbeatList":{"1":[{"status":0,"time":"2024-03-04 14:58:45.502"}]}}`, `{"config":{"slug":"x"},"publicGroupList":[null,{"monitorList":null}],"heartbeatList":{"1":null}}`},
+		},
+		{
+			site:  Site{URL: url.URL{Scheme: "https", Host: "gatus.example.com"}, Type: gatus.ServiceType, Monitors: MonitorsExpand},
+			seeds: []string{`[{"name":"queue","group":"core","key":"core_queue","results":[{"status":503,"success":false,"timestamp":"2024-03-04T14:59:30.511Z"}]}]`, `[{"name":"api","results":null},null]`},
+		},
+	}
+
+	for _, p := range providers {
+		for _, seed := range p.seeds {
+			f.Add([]byte(seed))
+		}
+	}
+	f.Add([]byte(`null`))
+	f.Add([]byte(`<!DOCTYPE html><html></html>`))
+
+	indicators := []string{"none", "minor", "major", status.IndicatorMaintenance}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		client := fetch.NewStaticClient(http.StatusOK, "application/json", body)
+
+		for _, p := range providers {
+			result := readSite(client, "Fuzz", p.site)
+			if result.err != nil {
+				require.NotEqual(t, ErrorUnsupportedServiceType, result.err.Code(), p.site.Type)
+				continue
+			}
+
+			details := []whatsupstatus.Details{result.details}
+			if e, ok := result.details.(status.Expander); ok {
+				details = append(details, e.Expand()...)
+			}
+
+			for _, d := range details {
+				require.Contains(t, indicators, d.Indicator(), "%s: %s", p.site.Type, d.Name())
+				_ = d.UpdatedAt()
+				_ = d.URL()
+			}
+
+			if summarizer, ok := result.details.(status.Summarizer); ok {
+				_ = summarizer.Summary()
+			}
+		}
+	})
+}
+
+type fuzzReader struct {
+	data []byte
+}
+
+func (fr fuzzReader) ReadFile(_ string) ([]byte, error) {
+	return fr.data, nil
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+// ErrorInvalidInclude is returned when an included configuration file cannot be read or includes itself
+const ErrorInvalidInclude = "INVALID_INCLUDE"
+
+// ErrorUntrustedRemoteSetting is returned when a remote configuration uses a setting only local files are trusted with
+const ErrorUntrustedRemoteSetting = "UNTRUSTED_REMOTE_SETTING"
+
+// ConfigKeyInclude is the reserved configuration key listing the files -- paths or globs, relative to the file
+// including them -- merged underneath the file. Only the remote entry can point at a URL.
+const ConfigKeyInclude = "include"
+
+// Limits stopping runaway include chains
+const (
+	maxIncludeDepth = 10
+	maxIncludeFiles = 100
+)
+
+// Resolved is the configuration after merging every layer: the included files, the file itself, and its local
+// overrides. Each layer is read in the current schema, so files of either version can be mixed. Objects are merged key
+// by key, any other value replaces the one before it, and null removes an entry, e.g. a site inherited from a team
+// file. Variables in the sites are expanded once every layer is merged; sites set by a remote configuration do not see
+// the environment.
+type Resolved struct {
+	Document map[string]interface{}
+	// Sources lists the files that set each setting and site, e.g. sites.Slack, in the order they were applied
+	Sources map[string][]string
+}
+
+// remoteSites returns the sites set by a remote configuration, even if local files change them as well
+func (r Resolved) remoteSites() map[string]bool {
+	remote := map[string]bool{}
+	for key, sources := range r.Sources {
+		name, isSite := strings.CutPrefix(key, ConfigKeySites+".")
+		if !isSite {
+			continue
+		}
+
+		for _, source := range sources {
+			if fetch.IsRemote(source) {
+				remote[name] = true
+			}
+		}
+	}
+
+	return remote
+}
+
+// LocalFilename returns the name of the local overrides for the configuration file, e.g. .whats-up.local.json for
+// .whats-up.json
+func LocalFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + ".local" + ext
+}
+
+// ResolveConfig reads the configuration file along with its includes and local overrides
+func ResolveConfig(r configuration.Reader, filename string) (Resolved, glitch.DataError) {
+	data, rErr := r.ReadFile(filename)
+	if rErr != nil {
+		return Resolved{}, glitch.NewDataError(rErr, ErrorConfigurationNotFound, "no What's Up configuration at "+filename)
+	}
+
+	return resolveConfig(r, filename, data)
+}
+
+func resolveConfig(r configuration.Reader, filename string, data []byte) (Resolved, glitch.DataError) {
+	l := layers{
+		reader:   r,
+		resolved: Resolved{Document: map[string]interface{}{}, Sources: map[string][]string{}},
+		visiting: map[string]bool{},
+	}
+
+	err := l.apply(filename, data, 0)
+	if err != nil {
+		return l.resolved, err
+	}
+
+	local := LocalFilename(filename)
+	if localData, rErr := r.ReadFile(local); rErr == nil {
+		err = l.apply(local, localData, 0)
+		if err != nil {
+			return l.resolved, err
+		}
+	}
+
+	sites, _ := l.resolved.Document[ConfigKeySites].(map[string]interface{})
+	renamed, err := expandSites(sites, os.LookupEnv, l.resolved.remoteSites())
+	if err != nil {
+		return l.resolved, err
+	}
+
+	for name, expanded := range renamed {
+		if len(expanded) == 1 && expanded[0] == name {
+			continue
+		}
+
+		sources := l.resolved.Sources[ConfigKeySites+"."+name]
+		delete(l.resolved.Sources, ConfigKeySites+"."+name)
+		for _, e := range expanded {
+			l.resolved.Sources[ConfigKeySites+"."+e] = sources
+		}
+	}
+
+	return l.resolved, nil
+}
+
+type layers struct {
+	reader   configuration.Reader
+	resolved Resolved
+	visiting map[string]bool
+	files    int
+}
+
+// apply merges the file's includes and then the file itself into the resolved configuration
+func (l *layers) apply(filename string, data []byte, depth int) glitch.DataError {
+	if depth > maxIncludeDepth {
+		return glitch.NewDataError(nil, ErrorInvalidInclude, "too many nested includes in "+filename)
+	}
+
+	l.files++
+	if l.files > maxIncludeFiles {
+		return glitch.NewDataError(nil, ErrorInvalidInclude, "too many included files in "+filename)
+	}
+
+	key := filepath.Clean(filename)
+	if l.visiting[key] {
+		return glitch.NewDataError(nil, ErrorInvalidInclude, filename+" includes itself")
+	}
+	l.visiting[key] = true
+	defer delete(l.visiting, key)
+
+	parsed, pErr := parseLayer(data)
+	if pErr != nil {
+		return glitch.NewDataError(pErr, ErrorUnableToParseConfiguration, "error parsing What's Up configuration "+filename)
+	}
+
+	doc, uErr := upgrade(parsed)
+	if uErr != nil {
+		return glitch.NewDataError(uErr, uErr.Code(), "error reading What's Up configuration "+filename)
+	}
+	delete(doc, ConfigKeySchema)
+
+	includes, iErr := includePatterns(doc[ConfigKeyInclude])
+	if iErr != nil {
+		return glitch.NewDataError(iErr, ErrorUnableToParseConfiguration, "error parsing the includes of "+filename)
+	}
+	delete(doc, ConfigKeyInclude)
+
+	if fetch.IsRemote(filename) {
+		err := checkRemoteLayer(filename, doc, includes)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range includes {
+		if fetch.IsRemote(pattern) {
+			if pattern != l.remoteURL() {
+				return glitch.NewDataError(nil, ErrorInvalidInclude, filename+" includes the URL "+pattern+
+					"; include files by path, or use the remote entry for a configuration published over HTTP")
+			}
+
+			err := l.applyInclude(filename, pattern, depth)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(filename), pattern)
+		}
+
+		for _, included := range expandInclude(pattern) {
+			err := l.applyInclude(filename, included, depth)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	l.record(filename, doc)
+	mergeLayer(l.resolved.Document, doc)
+
+	return nil
+}
+
+// checkRemoteLayer refuses what a configuration fetched over HTTP cannot be trusted with: includes, which would read
+// local files, and, in its HTTP settings or on its sites, secrets, auth, proxies, and TLS settings, which would read
+// local secrets and files or decide where requests go. Every site a remote configuration sets is read with the
+// settings it is allowed, whatever the local HTTP settings hold; see Site.
+func checkRemoteLayer(url string, doc map[string]interface{}, includes []string) glitch.DataError {
+	if len(includes) > 0 {
+		return glitch.NewDataError(nil, ErrorUntrustedRemoteSetting, "the remote configuration "+url+" cannot include other files")
+	}
+
+	settings, _ := doc[ConfigKeySettings].(map[string]interface{})
+	httpSettings, _ := settings[ConfigKeyHTTP].(map[string]interface{})
+	if setting := untrustedOption(httpSettings); setting != "" {
+		return glitch.NewDataError(nil, ErrorUntrustedRemoteSetting, "the remote configuration "+url+" cannot set "+
+			setting+" in the http settings; set it in a local file")
+	}
+
+	sites, _ := doc[ConfigKeySites].(map[string]interface{})
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		site, _ := sites[name].(map[string]interface{})
+		if setting := untrustedOption(site); setting != "" {
+			return glitch.NewDataError(nil, ErrorUntrustedRemoteSetting, "the remote configuration "+url+" cannot set "+
+				setting+" for "+name+"; set it in a local file")
+		}
+	}
+
+	return nil
+}
+
+// Keys of the HTTP settings only local files are trusted with
+const (
+	optionKeyHeaders = "headers"
+	optionKeyAuth    = "auth"
+	optionKeyProxy   = "proxy"
+	optionKeyNoProxy = "no_proxy"
+	optionKeyTLS     = "tls"
+)
+
+// untrustedOption returns the first of a set of HTTP settings only local files are trusted with: auth, a proxy, TLS
+// settings, or a header read from a secret; inline header values are not secrets
+func untrustedOption(options map[string]interface{}) string {
+	for _, key := range []string{optionKeyAuth, optionKeyProxy, optionKeyNoProxy, optionKeyTLS} {
+		if options[key] != nil {
+			return key
+		}
+	}
+
+	headers, _ := options[optionKeyHeaders].(map[string]interface{})
+	names := make([]string, 0, len(headers))
+	for name, h := range headers {
+		if _, isSecret := h.(map[string]interface{}); isSecret {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		return "the secret header " + names[0]
+	}
+
+	return ""
+}
+
+// record adds the file as a source of each setting and site it sets, and of any other entry besides the version
+func (l *layers) record(filename string, doc map[string]interface{}) {
+	add := func(key string, v interface{}) {
+		source := filename
+		if v == nil {
+			source += " (removed)"
+		}
+		l.resolved.Sources[key] = append(l.resolved.Sources[key], source)
+	}
+
+	for k, v := range doc {
+		section, isObject := v.(map[string]interface{})
+		switch {
+		case k == ConfigKeyVersion:
+		case (k == ConfigKeySettings || k == ConfigKeySites) && isObject:
+			for name, entry := range section {
+				add(k+"."+name, entry)
+			}
+		default:
+			add(k, v)
+		}
+	}
+}
+
+// remoteConfiguration is implemented by readers turning the remote entry into an include of its URL, e.g.
+// fetch.RemoteReader
+type remoteConfiguration interface {
+	RemoteURL() string
+}
+
+// remoteURL returns the URL of the remote configuration, the only URL a file can include
+func (l *layers) remoteURL() string {
+	r, ok := l.reader.(remoteConfiguration)
+	if !ok {
+		return ""
+	}
+
+	return r.RemoteURL()
+}
+
+// applyInclude reads a file, or the remote configuration, and applies it
+func (l *layers) applyInclude(filename, included string, depth int) glitch.DataError {
+	data, rErr := l.reader.ReadFile(included)
+	if rErr != nil {
+		return glitch.NewDataError(rErr, ErrorInvalidInclude, "unable to read "+included+" included by "+filename)
+	}
+
+	return l.apply(included, data, depth+1)
+}
+
+// parseLayer decodes a configuration file, keeping numbers as they were written
+func parseLayer(data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	return doc, nil
+}
+
+// includePatterns accepts a single pattern or a list of them
+func includePatterns(v interface{}) ([]string, error) {
+	switch include := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{include}, nil
+	case []interface{}:
+		patterns := make([]string, 0, len(include))
+		for _, p := range include {
+			s, ok := p.(string)
+			if !ok {
+				return nil, errors.New("include entries must be strings")
+			}
+			patterns = append(patterns, s)
+		}
+		return patterns, nil
+	default:
+		return nil, errors.New("include must be a path or a list of paths")
+	}
+}
+
+// expandInclude returns the files matching a glob in name order; a plain path is returned as is so a missing file is
+// reported
+func expandInclude(pattern string) []string {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+
+	return matches
+}
+
+// mergeLayer merges src into dst: objects are merged key by key, null removes the key, and anything else replaces it
+func mergeLayer(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+
+		srcObject, srcIsObject := v.(map[string]interface{})
+		dstObject, dstIsObject := dst[k].(map[string]interface{})
+		if srcIsObject && dstIsObject {
+			mergeLayer(dstObject, srcObject)
+			continue
+		}
+
+		if srcIsObject {
+			// Copy so null entries in a new object are dropped too
+			copied := map[string]interface{}{}
+			mergeLayer(copied, srcObject)
+			v = copied
+		}
+
+		dst[k] = v
+	}
+}
+
+// Write prints the merged configuration followed by the files each entry came from
+func (r Resolved) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(r.Document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "%s\n\nSources:\n", data)
+
+	keys := make([]string, 0, len(r.Sources))
+	width := 0
+	for k := range r.Sources {
+		keys = append(keys, k)
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, "  %-*s  %s\n", width, k, strings.Join(r.Sources[k], " > "))
+	}
+
+	return nil
+}
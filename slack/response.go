@@ -3,8 +3,11 @@ package slack
 
 import (
 	"github.com/sprak3000/go-glitch/glitch"
+	"github.com/sprak3000/go-whatsup-client/slack"
 	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
 	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
 )
 
 // ServiceType is the name we use for various checks
@@ -12,9 +15,23 @@ const ServiceType = "slack"
 
 // ClientReader implements the Reader interface for go-client based reading of a service's status
 type ClientReader struct {
+	// PageURL overrides where the status is read from when the client supports it, e.g. a local fake server
+	PageURL string
 }
 
 // ReadStatus handles communicating with the service to get its status details
 func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
-	return client.Slack()
+	g, ok := client.(fetch.Getter)
+	if !ok || cr.PageURL == "" || cr.PageURL == fetch.SlackURL {
+		return client.Slack()
+	}
+
+	var resp slack.Response
+
+	err := g.GetJSON(cr.PageURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
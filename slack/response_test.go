@@ -0,0 +1,25 @@
+package slack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sprak3000/go-whatsup-client/slack"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	details, err := ClientReader{}.ReadStatus(fetch.NewReplayClient("testdata"))
+	require.NoError(t, err)
+
+	resp, ok := details.(slack.Response)
+	require.True(t, ok)
+	require.Equal(t, "active", resp.Status)
+	require.Len(t, resp.ActiveIncidents, 1)
+
+	updatedAt, pErr := time.Parse(time.RFC3339, "2024-03-04T10:31:02-08:00")
+	require.NoError(t, pErr)
+	require.True(t, updatedAt.Equal(resp.DateUpdated))
+}
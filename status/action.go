@@ -0,0 +1,88 @@
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+)
+
+// Commands the plugin accepts when xbar runs one of the dropdown actions
+const (
+	CommandSnooze      = "snooze"
+	CommandAcknowledge = "ack"
+	CommandUnmute      = "unmute"
+)
+
+// SnoozeUntilResolved is the snooze duration argument that mutes a service until it reports no issues
+const SnoozeUntilResolved = "resolved"
+
+// IncidentTimeLayout is how the Acknowledge action passes the time of the incident; it keeps the fractional seconds
+// many status pages report so the acknowledgement matches the incident exactly
+const IncidentTimeLayout = time.RFC3339Nano
+
+// action renders an xbar menu item that runs the plugin with the given arguments and refreshes it afterwards
+func (o Overview) action(title string, args ...string) string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "%s | bash=%s", title, quoteParam(o.PluginPath))
+	for i, a := range args {
+		_, _ = fmt.Fprintf(&b, " param%d=%s", i+1, quoteParam(a))
+	}
+	b.WriteString(" terminal=false refresh=true")
+
+	return b.String()
+}
+
+// MuteKey returns what a service's snoozes and acknowledgements are kept under: its configuration key, so they last
+// when the site is renamed in the dropdown or its provider reports another name, or for a monitor of an expanded site,
+// its listed name, which starts with that key
+func (o Overview) MuteKey(d whatsupstatus.Details) string {
+	if _, isPart := d.(Part); isPart {
+		return d.Name()
+	}
+
+	if key, ok := o.Sites[d.Name()]; ok {
+		return key
+	}
+
+	return d.Name()
+}
+
+// muteActions returns the menu items that snooze or acknowledge a degraded service
+func (o Overview) muteActions(d whatsupstatus.Details) []string {
+	if o.PluginPath == "" {
+		return nil
+	}
+
+	key := o.MuteKey(d)
+
+	return []string{
+		o.action("Snooze 1h", CommandSnooze, key, "1h"),
+		o.action("Snooze 4h", CommandSnooze, key, "4h"),
+		o.action("Snooze until resolved", CommandSnooze, key, SnoozeUntilResolved),
+		o.action("Acknowledge", CommandAcknowledge, key, d.Indicator(), d.UpdatedAt().UTC().Format(IncidentTimeLayout)),
+	}
+}
+
+// unmuteActions returns the menu items for a muted service
+func (o Overview) unmuteActions(d whatsupstatus.Details) []string {
+	if o.PluginPath == "" {
+		return nil
+	}
+
+	return []string{
+		o.action("Unmute", CommandUnmute, o.MuteKey(d)),
+	}
+}
+
+// quoteParam wraps xbar parameters containing spaces in quotes
+func quoteParam(p string) string {
+	if strings.ContainsAny(p, " \t") {
+		return strconv.Quote(p)
+	}
+
+	return p
+}
@@ -0,0 +1,183 @@
+package status
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/history"
+	"github.com/sprak3000/xbar-whats-up/internal/golden"
+)
+
+func TestUnit_Overview_Display_Golden(t *testing.T) {
+	now := time.Date(2024, time.March, 4, 15, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	service := func(name, indicator string, age time.Duration) whatsupstatus.Details {
+		return goldenResponse{name: name, indicator: indicator, updatedAt: now.Add(-age)}
+	}
+
+	major := []whatsupstatus.Details{service("CircleCI", "major", 2*time.Hour)}
+	minor := []whatsupstatus.Details{service("Slack", "minor", 45*time.Minute), service("Sentry", "minor", 26*time.Hour)}
+	none := []whatsupstatus.Details{service("GitHub", "none", 72*time.Hour)}
+
+	fetchErr := OverviewError{
+		ServiceName: "Reddit",
+		ServiceURL:  "https://www.redditstatus.com/api/v2/status.json",
+		Error:       glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://www.redditstatus.com/api/v2/status.json"),
+	}
+
+	hintedErrs := []OverviewError{
+		{ServiceName: "Login", ServiceURL: "https://status.example.com/login", Error: glitch.NewDataError(nil, fetch.ErrorHTMLReceived, "https://status.example.com/login returned an HTML page instead of JSON")},
+		{ServiceName: "Logo", ServiceURL: "https://status.example.com/logo.png", Error: glitch.NewDataError(nil, fetch.ErrorUnexpectedContentType, `https://status.example.com/logo.png returned content type "image/png" instead of JSON`)},
+		{ServiceName: "Huge", ServiceURL: "https://status.example.com/history.json", Error: glitch.NewDataError(nil, fetch.ErrorResponseTooLarge, "the response from https://status.example.com/history.json is larger than 5242880 bytes")},
+	}
+
+	tests := map[string]Overview{
+		"no services":          {OverallStatus: "none", List: List{}},
+		"none":                 {OverallStatus: "none", LargestStringSize: 6, List: List{"none": none}},
+		"minor":                {OverallStatus: "minor", LargestStringSize: 6, List: List{"minor": minor}},
+		"major":                {OverallStatus: "major", LargestStringSize: 8, List: List{"major": major}},
+		"minor and none":       {OverallStatus: "minor", LargestStringSize: 6, List: List{"minor": minor, "none": none}},
+		"major and none":       {OverallStatus: "major", LargestStringSize: 8, List: List{"major": major, "none": none}},
+		"major and minor":      {OverallStatus: "major", LargestStringSize: 8, List: List{"major": major, "minor": minor}},
+		"every severity":       {OverallStatus: "major", LargestStringSize: 8, List: List{"major": major, "minor": minor, "none": none}},
+		"errors only":          {OverallStatus: "none", LargestStringSize: 6, List: List{}, Errors: []OverviewError{fetchErr}},
+		"errors with services": {OverallStatus: "minor", LargestStringSize: 6, List: List{"minor": minor, "none": none}, Errors: []OverviewError{fetchErr}},
+		"errors with hints":    {OverallStatus: "none", LargestStringSize: 5, List: List{}, Errors: hintedErrs},
+		"checked ages": {
+			OverallStatus:     "minor",
+			LargestStringSize: 6,
+			List:              List{"minor": minor, "none": none},
+			CheckedAt:         map[string]time.Time{"Slack": now.Add(-30 * time.Second), "Sentry": now.Add(-5 * time.Minute), "GitHub": now.Add(-3 * time.Hour)},
+		},
+		"error details": {
+			OverallStatus:     "none",
+			LargestStringSize: 6,
+			List:              List{},
+			Errors: []OverviewError{fetchErr, hintedErrs[0], {
+				ServiceName: "Status",
+				ServiceURL:  "https://status.example.com/api/v2/status.json",
+				Error:       glitch.NewDataError(errors.New("dial tcp: lookup status.example.com: no such host"), whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://status.example.com/api/v2/status.json"),
+			}},
+			Theme: Theme{ErrorDetails: true},
+		},
+		"warnings": {OverallStatus: "none", LargestStringSize: 6, List: List{"none": none}, Warnings: []string{"TLS verification is disabled for GitHub"}},
+		"long names": {
+			OverallStatus:     "minor",
+			LargestStringSize: 58,
+			List: List{
+				"minor": {service("Amazon Web Services - US East (N. Virginia) - Elastic Compute", "minor", time.Hour)},
+				"none":  none,
+			},
+		},
+		"unicode names": {
+			OverallStatus:     "major",
+			LargestStringSize: 9,
+			List: List{
+				"major": {service("Ünïcödé ☁️", "major", time.Hour)},
+				"none":  {service("日本語サービス", "none", time.Hour), service("Café", "none", time.Hour)},
+			},
+		},
+		"muted with actions": {
+			OverallStatus:     "minor",
+			LargestStringSize: 8,
+			List:              List{"minor": minor[:1], SeverityMuted: major},
+			PluginPath:        "/Users/me/Library/Application Support/xbar/plugins/whats-up.1h.cgo",
+		},
+		"summaries": {
+			OverallStatus:     "minor",
+			LargestStringSize: 6,
+			List: List{
+				"minor": {summarizedResponse{goldenResponse: goldenResponse{name: "Vendor", indicator: "minor", updatedAt: now.Add(-time.Hour)}, summary: []string{"API: Partial Service Disruption", "API (US East): Partial | Service Disruption"}}},
+				"none":  {summarizedResponse{goldenResponse: goldenResponse{name: "GitHub", indicator: "none", updatedAt: now.Add(-time.Hour)}, summary: []string{"Git: Operational"}}},
+			},
+			PluginPath: "/Users/me/Library/Application Support/xbar/plugins/whats-up.1h.cgo",
+		},
+		"inactive": {
+			OverallStatus:     "none",
+			LargestStringSize: 8,
+			List:              List{"none": none, SeverityInactive: major},
+		},
+		"custom theme, relative dates": {
+			OverallStatus:     "major",
+			LargestStringSize: 8,
+			List:              List{"major": major, "minor": minor, "none": none},
+			Errors:            []OverviewError{fetchErr},
+			Theme: Theme{
+				Icons:      Icons{Major: Icon{Text: "FIRE"}},
+				Colors:     Colors{Major: "#ff0000", Date: "37"},
+				Font:       "Menlo",
+				Size:       13,
+				DateFormat: DateFormatRelative,
+			}.Resolve(true),
+		},
+		"reliability": {
+			OverallStatus:     "none",
+			LargestStringSize: 6,
+			List:              List{"none": none},
+			Theme:             Theme{Stats: "7d"},
+			Reliability: history.Report{
+				{Name: "GitHub", Kind: history.KindSite, Window: "7d", Polls: 168, None: 98.21, Minor: 1.79, Incidents: 2, MTTR: history.Duration(90 * time.Minute), LongestOutage: history.Duration(2 * time.Hour)},
+			},
+		},
+	}
+
+	for _, mode := range []string{TitleModeIcon, TitleModeCounts, TitleModeRatio, TitleModeWorst, TitleModeCycle} {
+		tests["title "+mode] = Overview{
+			OverallStatus:     "major",
+			LargestStringSize: 8,
+			List:              List{"major": major, "minor": minor, "none": none},
+			Theme:             Theme{Title: mode},
+		}
+	}
+
+	for name, o := range tests {
+		t.Run(name, func(t *testing.T) {
+			o.Clock = clock
+
+			var buf bytes.Buffer
+			o.Display(&buf)
+
+			golden.Require(t, buf.Bytes())
+		})
+	}
+}
+
+type goldenResponse struct {
+	name      string
+	indicator string
+	updatedAt time.Time
+}
+
+func (gr goldenResponse) Indicator() string {
+	return gr.indicator
+}
+
+func (gr goldenResponse) Name() string {
+	return gr.name
+}
+
+func (gr goldenResponse) UpdatedAt() time.Time {
+	return gr.updatedAt
+}
+
+func (gr goldenResponse) URL() string {
+	return "https://status.example.com/" + url.PathEscape(gr.name)
+}
+
+// summarizedResponse is a service breaking its status down into a submenu
+type summarizedResponse struct {
+	goldenResponse
+	summary []string
+}
+
+func (sr summarizedResponse) Summary() []string {
+	return sr.summary
+}
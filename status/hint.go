@@ -0,0 +1,55 @@
+package status
+
+import (
+	"strings"
+
+	"github.com/sprak3000/go-glitch/glitch"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+// errorHints suggest a fix for the errors a misconfigured site commonly runs into
+var errorHints = map[string]string{
+	fetch.ErrorHTMLReceived:               "The URL returned a web page; point it at the status API instead.",
+	fetch.ErrorUnexpectedContentType:      "The URL did not return JSON; check it points at the status API.",
+	fetch.ErrorResponseTooLarge:           "The response is too large; check the URL or raise max_body_size.",
+	fetch.ErrorUnableToResolveSecret:      "A secret for this site could not be read; check its env, file, or command.",
+	fetch.ErrorUnsupportedAuthType:        "The auth type must be bearer or basic.",
+	fetch.ErrorUnableToConfigureTransport: "Check the proxy and TLS settings for this site.",
+}
+
+// errorHint returns the hint for the error, or an empty string when we have none
+func errorHint(err glitch.DataError) string {
+	if err == nil {
+		return ""
+	}
+
+	return errorHints[err.Code()]
+}
+
+// errorDetails returns the lines describing the error: its code, the page requested, and the error it wraps
+func errorDetails(e OverviewError) []string {
+	details := []string{"Code: " + e.Error.Code()}
+
+	if e.ServiceURL != "" {
+		details = append(details, "URL: "+e.ServiceURL)
+	}
+
+	if inner := e.Error.Inner(); inner != nil {
+		details = append(details, "Cause: "+inner.Error())
+	}
+
+	for i, d := range details {
+		details[i] = menuText(d)
+	}
+
+	return details
+}
+
+// menuEscaper keeps text from breaking an xbar item: a pipe starts the xbar parameters and a new line ends the item
+var menuEscaper = strings.NewReplacer("|", "¦", "\n", " ", "\r", " ")
+
+// menuText returns the text safe to show as an xbar item
+func menuText(s string) string {
+	return menuEscaper.Replace(s)
+}
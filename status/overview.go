@@ -8,11 +8,35 @@ import (
 
 	"github.com/sprak3000/go-glitch/glitch"
 	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+
+	"github.com/sprak3000/xbar-whats-up/history"
 )
 
+// SeverityMuted is the List key holding services snoozed or acknowledged from the dropdown
+const SeverityMuted = "muted"
+
+// IndicatorMaintenance is reported by services under planned maintenance; it counts as no issues
+const IndicatorMaintenance = "maintenance"
+
+// SeverityInactive is the List key holding services outside their active hours and days; like muted services, they do
+// not count towards the overall status
+const SeverityInactive = "inactive"
+
 // List is a mapping of status codes to services reporting that status code
 type List map[string][]whatsupstatus.Details
 
+// OverallStatus returns the most severe status code in the list; muted services do not count
+func (l List) OverallStatus() string {
+	switch {
+	case len(l["major"]) > 0:
+		return "major"
+	case len(l["minor"]) > 0:
+		return "minor"
+	default:
+		return "none"
+	}
+}
+
 // OverviewError bundles the details of a failed overview request
 type OverviewError struct {
 	ServiceName string
@@ -21,6 +45,45 @@ type OverviewError struct {
 	Error       glitch.DataError
 }
 
+// Summarizer is implemented by details able to break their status down, e.g. into the components affected or the
+// incidents open; each line is shown in a submenu under the service
+type Summarizer interface {
+	Summary() []string
+}
+
+// Expander is implemented by details made of monitors that can each be listed as a service of its own
+type Expander interface {
+	Expand() []whatsupstatus.Details
+}
+
+// Part is a monitor of an expanded service
+type Part struct {
+	PartName      string
+	PartIndicator string
+	PartUpdatedAt time.Time
+	PartURL       string
+}
+
+// Indicator returns the status of the monitor
+func (p Part) Indicator() string {
+	return p.PartIndicator
+}
+
+// Name returns the service and monitor names, e.g. Internal / API
+func (p Part) Name() string {
+	return p.PartName
+}
+
+// UpdatedAt returns when the monitor last reported
+func (p Part) UpdatedAt() time.Time {
+	return p.PartUpdatedAt
+}
+
+// URL returns the page showing the monitor
+func (p Part) URL() string {
+	return p.PartURL
+}
+
 // Overview provides an overall status for all services monitored -- most severe status wins -- along with all the
 // services categorized by status
 type Overview struct {
@@ -28,37 +91,118 @@ type Overview struct {
 	LargestStringSize int
 	List
 	Errors []OverviewError
+	// Warnings are shown at the top of the dropdown, e.g. for sites skipping TLS verification
+	Warnings []string
+	// CheckedAt holds when each service last confirmed its status; services served from an earlier run show its age
+	CheckedAt map[string]time.Time
+	// Sites holds the configuration key of each service listed, by the name it is listed under; they differ when a
+	// provider reports its own name or a site is expanded into its monitors
+	Sites map[string]string
+	Theme Theme
+	// PluginPath is the plugin executable; when set, the dropdown offers actions to snooze and acknowledge services
+	PluginPath string
+	// Reliability holds the per site history summaries for the window named by the theme's Stats setting
+	Reliability history.Report
+	// Clock provides the current time used when rendering; time.Now is used when not set
+	Clock Clock
+}
+
+// Clock returns the current time
+type Clock func() time.Time
+
+func (o Overview) now() time.Time {
+	if o.Clock == nil {
+		return time.Now()
+	}
+
+	return o.Clock()
+}
+
+// MinimumAge is how old a service's status has to be before its age is shown
+const MinimumAge = time.Minute
+
+// date returns the date shown for the service, followed by the age of its status when it was served from an earlier run
+func (o Overview) date(theme Theme, d whatsupstatus.Details, now time.Time) string {
+	date := theme.formatDate(d.UpdatedAt(), now)
+
+	checkedAt, ok := o.CheckedAt[d.Name()]
+	if !ok || now.Sub(checkedAt) < MinimumAge {
+		return date
+	}
+
+	return date + " · checked " + relativeDate(checkedAt, now)
 }
 
 // Display outputs the data in the xbar format
 func (o Overview) Display(w io.Writer) {
-	switch o.OverallStatus {
-	case "major":
-		_, _ = fmt.Fprintln(w, "🔴")
-	case "minor":
-		_, _ = fmt.Fprintln(w, "🟠")
-	default:
-		_, _ = fmt.Fprintln(w, "🟢")
+	theme := o.Theme.withDefaults()
+	now := o.now()
+
+	for _, l := range o.title(theme) {
+		_, _ = fmt.Fprintln(w, l)
+	}
+
+	if len(o.Warnings) > 0 {
+		_, _ = fmt.Fprintln(w, "---")
+		for _, warning := range o.Warnings {
+			_, _ = fmt.Fprintln(w, theme.warning(warning))
+		}
 	}
 
-	displayDetails(w, o.LargestStringSize, o.List["major"], "\u001B[31;1m")
-	displayDetails(w, o.LargestStringSize, o.List["minor"], "\u001b[38;5;208m")
-	displayDetails(w, o.LargestStringSize, o.List["none"], "\u001B[32;1m")
+	o.displayDetails(w, theme, now, o.List["major"], theme.Colors.Major, o.muteActions)
+	o.displayDetails(w, theme, now, o.List["minor"], theme.Colors.Minor, o.muteActions)
+	o.displayDetails(w, theme, now, o.List["none"], theme.Colors.None, nil)
+	o.displayDetails(w, theme, now, o.List[SeverityMuted], theme.Colors.Muted, o.unmuteActions)
+	o.displayDetails(w, theme, now, o.List[SeverityInactive], theme.Colors.Muted, nil)
 
 	if len(o.Errors) > 0 {
 		_, _ = fmt.Fprintln(w, "---")
 		for _, e := range o.Errors {
-			_, _ = fmt.Fprintf(w, "⁉️ %s%-*s%s%s %s | font=Monaco href=%s\n", "\u001B[31;1m", o.LargestStringSize+2, e.ServiceName, "\u001b[0m", "\u001b[30m", time.Now().Format("2006 Jan 02"), e.ServiceURL)
+			_, _ = fmt.Fprintln(w, theme.line("⁉️ ", theme.Colors.Error, o.LargestStringSize+2, e.ServiceName, theme.formatDate(now, now), e.ServiceURL))
 			_, _ = fmt.Fprintln(w, "-- Error fetching site status.")
+			if hint := errorHint(e.Error); hint != "" {
+				_, _ = fmt.Fprintln(w, "-- "+hint)
+			}
+			if theme.ErrorDetails && e.Error != nil {
+				_, _ = fmt.Fprintln(w, "-- Details")
+				for _, d := range errorDetails(e) {
+					_, _ = fmt.Fprintf(w, "---- %s | font=%s\n", d, theme.Font)
+				}
+			}
+		}
+	}
+
+	if theme.Stats != "" && len(o.Reliability) > 0 {
+		_, _ = fmt.Fprintln(w, "---")
+		_, _ = fmt.Fprintf(w, "Reliability (%s)\n", theme.Stats)
+		for _, s := range o.Reliability {
+			_, _ = fmt.Fprintf(w, "-- %-*s %6.2f%% up  %d incidents  MTTR %s  longest %s | font=%s\n", o.LargestStringSize+2, s.Name, s.None, s.Incidents, s.MTTR, s.LongestOutage, theme.Font)
 		}
 	}
 }
 
-func displayDetails(w io.Writer, largestStringSize int, details []whatsupstatus.Details, detailColor string) {
+func (o Overview) displayDetails(w io.Writer, theme Theme, now time.Time, details []whatsupstatus.Details, detailColor Color, actions func(d whatsupstatus.Details) []string) {
 	if len(details) > 0 {
 		_, _ = fmt.Fprintln(w, "---")
 		for _, v := range details {
-			_, _ = fmt.Fprintf(w, "%s%-*s%s%s %s | font=Monaco href=%s\n", detailColor, largestStringSize+5, v.Name(), "\u001b[0m", "\u001b[30m", v.UpdatedAt().Format("2006 Jan 02"), v.URL())
+			_, _ = fmt.Fprintln(w, theme.line("", detailColor, o.LargestStringSize+5, v.Name(), o.date(theme, v, now), v.URL()))
+
+			var summary []string
+			if s, ok := v.(Summarizer); ok {
+				summary = s.Summary()
+			}
+			for _, l := range summary {
+				_, _ = fmt.Fprintf(w, "-- %s | font=%s\n", menuText(l), theme.Font)
+			}
+
+			if actions != nil {
+				for i, a := range actions(v) {
+					if i == 0 && len(summary) > 0 {
+						_, _ = fmt.Fprintln(w, "-----")
+					}
+					_, _ = fmt.Fprintln(w, "-- "+a)
+				}
+			}
 		}
 	}
 }
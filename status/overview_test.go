@@ -2,6 +2,7 @@ package status
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 	"time"
 
@@ -122,6 +123,120 @@ func TestUnit_Overview_Display(t *testing.T) {
 				require.Equal(t, "🟢\n---\n\x1b[32;1mTest Service     \x1b[0m\x1b[30m "+nowFormatted+" | font=Monaco href=https://test.service/\n---\n⁉️ \x1b[31;1mTest Service  \x1b[0m\x1b[30m "+nowFormatted+" | font=Monaco href=https://test.service/\n-- Error fetching site status.\n", buf.String())
 			},
 		},
+		"base path- custom theme": {
+			validate: func(t *testing.T) {
+				o := Overview{
+					OverallStatus:     "minor",
+					LargestStringSize: 12,
+					List: map[string][]whatsupstatus.Details{
+						"minor": {
+							testResponse{
+								updatedAt: now,
+							},
+						},
+						"none": {
+							testResponse{
+								updatedAt: now,
+							},
+						},
+					},
+					Theme: Theme{
+						Icons: Icons{
+							Minor: Icon{Text: "WARN", Image: "aW1hZ2U="},
+						},
+						Colors: Colors{
+							Minor: "#ff8800",
+							None:  "32",
+							Date:  "37",
+						},
+						Font: "Menlo",
+						Size: 12,
+					},
+				}
+
+				var buf bytes.Buffer
+				o.Display(&buf)
+
+				require.Equal(t, "WARN | image=aW1hZ2U=\n---\nTest Service     \x1b[37m "+nowFormatted+" | font=Menlo size=12 color=#ff8800 href=https://test.service/\n---\n\x1b[32mTest Service     \x1b[0m\x1b[37m "+nowFormatted+" | font=Menlo size=12 href=https://test.service/\n", buf.String())
+			},
+		},
+		"base path- mute actions": {
+			validate: func(t *testing.T) {
+				o := Overview{
+					OverallStatus:     "minor",
+					LargestStringSize: 12,
+					List: map[string][]whatsupstatus.Details{
+						"minor": {
+							testResponse{
+								updatedAt: time.Unix(1709290800, 531000000),
+							},
+						},
+						SeverityMuted: {
+							testResponse{
+								updatedAt: now,
+							},
+						},
+					},
+					Theme: Theme{
+						DateFormat: "2006",
+					},
+					PluginPath: "/xbar plugins/whats-up",
+				}
+
+				var buf bytes.Buffer
+				o.Display(&buf)
+
+				action := " | bash=\"/xbar plugins/whats-up\" param1=%s param2=\"Test Service\"%s terminal=false refresh=true\n"
+				require.Equal(t, "🟠\n---\n\x1b[38;5;208mTest Service     \x1b[0m\x1b[30m 2024 | font=Monaco href=https://test.service/\n"+
+					"-- Snooze 1h"+fmt.Sprintf(action, "snooze", " param3=1h")+
+					"-- Snooze 4h"+fmt.Sprintf(action, "snooze", " param3=4h")+
+					"-- Snooze until resolved"+fmt.Sprintf(action, "snooze", " param3=resolved")+
+					"-- Acknowledge"+fmt.Sprintf(action, "ack", " param3= param4=2024-03-01T11:00:00.531Z")+
+					"---\n\x1b[90mTest Service     \x1b[0m\x1b[30m "+now.Format("2006")+" | font=Monaco href=https://test.service/\n"+
+					"-- Unmute"+fmt.Sprintf(action, "unmute", ""), buf.String())
+			},
+		},
+		"base path- unknown update time": {
+			validate: func(t *testing.T) {
+				o := Overview{
+					OverallStatus:     "none",
+					LargestStringSize: 12,
+					List: map[string][]whatsupstatus.Details{
+						"none": {
+							testResponse{},
+						},
+					},
+				}
+
+				var buf bytes.Buffer
+				o.Display(&buf)
+
+				require.Equal(t, "🟢\n---\n\x1b[32;1mTest Service     \x1b[0m\x1b[30m  | font=Monaco href=https://test.service/\n", buf.String())
+			},
+		},
+		"base path- relative dates": {
+			validate: func(t *testing.T) {
+				o := Overview{
+					OverallStatus:     "none",
+					LargestStringSize: 12,
+					List: map[string][]whatsupstatus.Details{
+						"none": {
+							testResponse{
+								updatedAt: now.Add(-3 * time.Hour),
+							},
+						},
+					},
+					Theme: Theme{
+						DateFormat: DateFormatRelative,
+					},
+				}
+
+				var buf bytes.Buffer
+				o.Display(&buf)
+
+				require.Equal(t, "🟢\n---\n\x1b[32;1mTest Service     \x1b[0m\x1b[30m 3h ago | font=Monaco href=https://test.service/\n", buf.String())
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -149,3 +264,11 @@ func (tr testResponse) UpdatedAt() time.Time {
 func (tr testResponse) URL() string {
 	return "https://test.service/"
 }
+
+func TestUnit_Overview_MuteKey(t *testing.T) {
+	o := Overview{Sites: map[string]string{"Test Service": "Team service", "Internal / api": "Internal"}}
+
+	require.Equal(t, "Team service", o.MuteKey(testResponse{}))
+	require.Equal(t, "Internal / api", o.MuteKey(Part{PartName: "Internal / api"}))
+	require.Equal(t, "Test Service", Overview{}.MuteKey(testResponse{}))
+}
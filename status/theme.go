@@ -0,0 +1,305 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateFormatRelative is the date format value that renders dates as the time elapsed since, e.g. "3h ago"
+const DateFormatRelative = "relative"
+
+const (
+	ansiEscape = "\u001b["
+	ansiReset  = "\u001b[0m"
+)
+
+// Icon is what we show in the menu bar for an overall status; either text (emoji included) or a base64 encoded image
+type Icon struct {
+	Text  string `json:"text"`
+	Image string `json:"image"`
+}
+
+// UnmarshalJSON allows an icon to be given as a plain string for text icons or as an object for image icons
+func (i *Icon) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*i = Icon{Text: text}
+		return nil
+	}
+
+	type tmpIcon Icon
+	return json.Unmarshal(data, (*tmpIcon)(i))
+}
+
+func (i Icon) isZero() bool {
+	return i.Text == "" && i.Image == ""
+}
+
+// label returns the icon text for use inside other text, falling back to the given name for image icons
+func (i Icon) label(fallback string) string {
+	if i.Text == "" {
+		return fallback
+	}
+
+	return i.Text
+}
+
+// Icons holds the menu bar icon used for each overall status
+type Icons struct {
+	Major Icon `json:"major"`
+	Minor Icon `json:"minor"`
+	None  Icon `json:"none"`
+}
+
+// Color is either an ANSI SGR sequence -- "31;1" or the full "\u001b[31;1m" escape -- or a hex color such as "#ff0000"
+type Color string
+
+func (c Color) isHex() bool {
+	return strings.HasPrefix(string(c), "#")
+}
+
+// ansi returns the escape sequence for the color or an empty string for hex colors
+func (c Color) ansi() string {
+	if c == "" || c.isHex() {
+		return ""
+	}
+
+	if strings.HasPrefix(string(c), ansiEscape) {
+		return string(c)
+	}
+
+	return ansiEscape + string(c) + "m"
+}
+
+// Colors holds the colors used for each severity and for the dates shown next to each service
+type Colors struct {
+	Major Color `json:"major"`
+	Minor Color `json:"minor"`
+	None  Color `json:"none"`
+	Error Color `json:"error"`
+	Muted Color `json:"muted"`
+	Date  Color `json:"date"`
+}
+
+// Theme controls how an Overview is rendered; Light and Dark hold overrides applied based on the macOS appearance
+type Theme struct {
+	Title      string `json:"title"`
+	Icons      Icons  `json:"icons"`
+	Colors     Colors `json:"colors"`
+	Font       string `json:"font"`
+	Size       int    `json:"size"`
+	DateFormat string `json:"date_format"`
+	// Stats names the history window -- 24h, 7d, or 30d -- to summarize in the dropdown; empty hides the summary
+	Stats string `json:"stats"`
+	// ErrorDetails adds a submenu under each error with its code and message
+	ErrorDetails bool   `json:"error_details"`
+	Light        *Theme `json:"light,omitempty"`
+	Dark         *Theme `json:"dark,omitempty"`
+}
+
+// DefaultTheme returns the look of the plugin when nothing has been configured
+func DefaultTheme() Theme {
+	return Theme{
+		Title: TitleModeIcon,
+		Icons: Icons{
+			Major: Icon{Text: "🔴"},
+			Minor: Icon{Text: "🟠"},
+			None:  Icon{Text: "🟢"},
+		},
+		Colors: Colors{
+			Major: "\u001B[31;1m",
+			Minor: "\u001b[38;5;208m",
+			None:  "\u001B[32;1m",
+			Error: "\u001B[31;1m",
+			Muted: "\u001b[90m",
+			Date:  "\u001b[30m",
+		},
+		Font:       "Monaco",
+		DateFormat: "2006 Jan 02",
+		Dark: &Theme{
+			Colors: Colors{
+				Date: "\u001b[37m",
+			},
+		},
+	}
+}
+
+// Resolve layers the defaults, their light or dark variant, the theme, and then its own variant; xbar tells us which
+// variant through the XBARDarkMode environment variable. A value the theme sets outside its variants wins over the
+// default variants.
+func (t Theme) Resolve(darkMode bool) Theme {
+	d := DefaultTheme()
+
+	if darkMode {
+		return d.merge(d.Dark).merge(&t).merge(t.Dark)
+	}
+
+	return d.merge(d.Light).merge(&t).merge(t.Light)
+}
+
+// withDefaults fills in anything not set in the theme, ignoring the light and dark variants
+func (t Theme) withDefaults() Theme {
+	return DefaultTheme().merge(&t)
+}
+
+// merge returns a copy of the theme with every value set in the override replacing its own
+func (t Theme) merge(o *Theme) Theme {
+	t.Light = nil
+	t.Dark = nil
+
+	if o == nil {
+		return t
+	}
+
+	if o.Title != "" {
+		t.Title = o.Title
+	}
+
+	if !o.Icons.Major.isZero() {
+		t.Icons.Major = o.Icons.Major
+	}
+	if !o.Icons.Minor.isZero() {
+		t.Icons.Minor = o.Icons.Minor
+	}
+	if !o.Icons.None.isZero() {
+		t.Icons.None = o.Icons.None
+	}
+
+	if o.Colors.Major != "" {
+		t.Colors.Major = o.Colors.Major
+	}
+	if o.Colors.Minor != "" {
+		t.Colors.Minor = o.Colors.Minor
+	}
+	if o.Colors.None != "" {
+		t.Colors.None = o.Colors.None
+	}
+	if o.Colors.Error != "" {
+		t.Colors.Error = o.Colors.Error
+	}
+	if o.Colors.Muted != "" {
+		t.Colors.Muted = o.Colors.Muted
+	}
+	if o.Colors.Date != "" {
+		t.Colors.Date = o.Colors.Date
+	}
+
+	if o.Font != "" {
+		t.Font = o.Font
+	}
+	if o.Size > 0 {
+		t.Size = o.Size
+	}
+	if o.DateFormat != "" {
+		t.DateFormat = o.DateFormat
+	}
+	if o.Stats != "" {
+		t.Stats = o.Stats
+	}
+	if o.ErrorDetails {
+		t.ErrorDetails = true
+	}
+
+	return t
+}
+
+// icon returns the menu bar line for the given overall status
+func (t Theme) icon(overallStatus string) string {
+	var i Icon
+
+	switch overallStatus {
+	case "major":
+		i = t.Icons.Major
+	case "minor":
+		i = t.Icons.Minor
+	default:
+		i = t.Icons.None
+	}
+
+	if i.Image != "" {
+		return i.Text + " | image=" + i.Image
+	}
+
+	return i.Text
+}
+
+// formatDate renders a date using the configured format; services that do not report when they changed have no date
+func (t Theme) formatDate(d, now time.Time) string {
+	if d.IsZero() {
+		return ""
+	}
+
+	if t.DateFormat != DateFormatRelative {
+		return d.Format(t.DateFormat)
+	}
+
+	return relativeDate(d, now)
+}
+
+// relativeDate renders how long ago the date was, e.g. 3h ago
+func relativeDate(d, now time.Time) string {
+	elapsed := now.Sub(d)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	}
+}
+
+// warning formats a line calling out a risky setting in the error color
+func (t Theme) warning(text string) string {
+	var b strings.Builder
+
+	b.WriteString("⚠️ ")
+	if a := t.Colors.Error.ansi(); a != "" {
+		_, _ = fmt.Fprintf(&b, "%s%s%s", a, text, ansiReset)
+	} else {
+		b.WriteString(text)
+	}
+	_, _ = fmt.Fprintf(&b, " | font=%s", t.Font)
+
+	if t.Size > 0 {
+		_, _ = fmt.Fprintf(&b, " size=%d", t.Size)
+	}
+
+	if t.Colors.Error.isHex() {
+		_, _ = fmt.Fprintf(&b, " color=%s", t.Colors.Error)
+	}
+
+	return b.String()
+}
+
+// line renders a dropdown line with a label in the given color followed by a date
+func (t Theme) line(prefix string, color Color, width int, label string, date string, href string) string {
+	var b strings.Builder
+
+	b.WriteString(prefix)
+	if a := color.ansi(); a != "" {
+		_, _ = fmt.Fprintf(&b, "%s%-*s%s", a, width, label, ansiReset)
+	} else {
+		_, _ = fmt.Fprintf(&b, "%-*s", width, label)
+	}
+	_, _ = fmt.Fprintf(&b, "%s %s | font=%s", t.Colors.Date.ansi(), date, t.Font)
+
+	if t.Size > 0 {
+		_, _ = fmt.Fprintf(&b, " size=%d", t.Size)
+	}
+
+	switch {
+	case color.isHex():
+		_, _ = fmt.Fprintf(&b, " color=%s", color)
+	case t.Colors.Date.isHex():
+		_, _ = fmt.Fprintf(&b, " color=%s", t.Colors.Date)
+	}
+
+	_, _ = fmt.Fprintf(&b, " href=%s", href)
+
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package status
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Theme_Resolve(t *testing.T) {
+	tests := map[string]struct {
+		themeJSON     []byte
+		darkMode      bool
+		expectedTheme func() Theme
+	}{
+		"base path- nothing configured, light mode": {
+			themeJSON: []byte(`{}`),
+			expectedTheme: func() Theme {
+				d := DefaultTheme()
+				d.Dark = nil
+				return d
+			},
+		},
+		"base path- nothing configured, dark mode": {
+			themeJSON: []byte(`{}`),
+			darkMode:  true,
+			expectedTheme: func() Theme {
+				d := DefaultTheme()
+				d.Dark = nil
+				d.Colors.Date = "\u001b[37m"
+				return d
+			},
+		},
+		"base path- base color without a dark variant, dark mode": {
+			themeJSON: []byte(`{"colors":{"date":"90"}}`),
+			darkMode:  true,
+			expectedTheme: func() Theme {
+				d := DefaultTheme()
+				d.Dark = nil
+				d.Colors.Date = "90"
+				return d
+			},
+		},
+		"base path- base color without a light variant, light mode": {
+			themeJSON: []byte(`{"colors":{"date":"90"}}`),
+			expectedTheme: func() Theme {
+				d := DefaultTheme()
+				d.Dark = nil
+				d.Colors.Date = "90"
+				return d
+			},
+		},
+		"base path- variants override the base settings": {
+			themeJSON: []byte(`{"icons":{"major":"FIRE","none":{"image":"aW1hZ2U="}},"colors":{"date":"90"},"font":"Menlo","light":{"colors":{"date":"#333333"}},"dark":{"colors":{"date":"#cccccc"},"size":14}}`),
+			darkMode:  true,
+			expectedTheme: func() Theme {
+				d := DefaultTheme()
+				d.Dark = nil
+				d.Icons.Major = Icon{Text: "FIRE"}
+				d.Icons.None = Icon{Image: "aW1hZ2U="}
+				d.Colors.Date = "#cccccc"
+				d.Font = "Menlo"
+				d.Size = 14
+				return d
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var theme Theme
+			require.NoError(t, json.Unmarshal(tc.themeJSON, &theme))
+			require.Equal(t, tc.expectedTheme(), theme.Resolve(tc.darkMode))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+)
+
+// Title modes control what we show in the menu bar
+const (
+	// TitleModeIcon shows the icon of the overall status
+	TitleModeIcon = "icon"
+	// TitleModeCounts shows the number of services per degraded severity, e.g. "2🔴 1🟠"
+	TitleModeCounts = "counts"
+	// TitleModeRatio shows the number of degraded services out of all services, e.g. "3/40 degraded"
+	TitleModeRatio = "ratio"
+	// TitleModeWorst shows the icon of the overall status along with the name of the worst service
+	TitleModeWorst = "worst"
+	// TitleModeCycle shows one line per degraded service; xbar cycles through them in the menu bar
+	TitleModeCycle = "cycle"
+)
+
+// title returns the menu bar lines for the overview; xbar cycles through every line printed before the first separator
+func (o Overview) title(theme Theme) []string {
+	major := byName(o.List["major"])
+	minor := byName(o.List["minor"])
+
+	if len(major)+len(minor) == 0 {
+		return []string{theme.icon(o.OverallStatus)}
+	}
+
+	switch theme.Title {
+	case TitleModeCounts:
+		var counts []string
+		if len(major) > 0 {
+			counts = append(counts, fmt.Sprintf("%d%s", len(major), theme.Icons.Major.label("major")))
+		}
+		if len(minor) > 0 {
+			counts = append(counts, fmt.Sprintf("%d%s", len(minor), theme.Icons.Minor.label("minor")))
+		}
+		return []string{strings.Join(counts, " ")}
+	case TitleModeRatio:
+		total := len(major) + len(minor) + len(o.List["none"])
+		return []string{fmt.Sprintf("%d/%d degraded", len(major)+len(minor), total)}
+	case TitleModeWorst:
+		if len(major) > 0 {
+			return []string{theme.Icons.Major.label("major") + " " + major[0].Name()}
+		}
+		return []string{theme.Icons.Minor.label("minor") + " " + minor[0].Name()}
+	case TitleModeCycle:
+		var lines []string
+		for _, d := range major {
+			lines = append(lines, theme.Icons.Major.label("major")+" "+d.Name())
+		}
+		for _, d := range minor {
+			lines = append(lines, theme.Icons.Minor.label("minor")+" "+d.Name())
+		}
+		return lines
+	default:
+		return []string{theme.icon(o.OverallStatus)}
+	}
+}
+
+// byName returns a copy of the services sorted by name; the overview lists them in the order they answered, which
+// changes from one run to the next
+func byName(list []whatsupstatus.Details) []whatsupstatus.Details {
+	sorted := append([]whatsupstatus.Details(nil), list...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+
+	return sorted
+}
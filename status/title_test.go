@@ -0,0 +1,105 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_Overview_title(t *testing.T) {
+	o := Overview{
+		OverallStatus: "major",
+		List: map[string][]whatsupstatus.Details{
+			"major": {
+				namedResponse{name: "GitHub"},
+				namedResponse{name: "CircleCI"},
+			},
+			"minor": {
+				namedResponse{name: "Slack"},
+			},
+			"none": {
+				namedResponse{name: "Sentry"},
+				namedResponse{name: "Reddit"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		overview       Overview
+		title          string
+		icons          Icons
+		expectedTitles []string
+	}{
+		"base path- icon": {
+			overview:       o,
+			title:          TitleModeIcon,
+			expectedTitles: []string{"🔴"},
+		},
+		"base path- counts": {
+			overview:       o,
+			title:          TitleModeCounts,
+			expectedTitles: []string{"2🔴 1🟠"},
+		},
+		"base path- counts with image icons": {
+			overview:       o,
+			title:          TitleModeCounts,
+			icons:          Icons{Major: Icon{Image: "aW1hZ2U="}},
+			expectedTitles: []string{"2major 1🟠"},
+		},
+		"base path- ratio": {
+			overview:       o,
+			title:          TitleModeRatio,
+			expectedTitles: []string{"3/5 degraded"},
+		},
+		"base path- worst": {
+			overview:       o,
+			title:          TitleModeWorst,
+			expectedTitles: []string{"🔴 CircleCI"},
+		},
+		"base path- cycle": {
+			overview:       o,
+			title:          TitleModeCycle,
+			expectedTitles: []string{"🔴 CircleCI", "🔴 GitHub", "🟠 Slack"},
+		},
+		"base path- nothing degraded falls back to the icon": {
+			overview: Overview{
+				OverallStatus: "none",
+				List: map[string][]whatsupstatus.Details{
+					"none": {
+						namedResponse{name: "Sentry"},
+					},
+				},
+			},
+			title:          TitleModeCounts,
+			expectedTitles: []string{"🟢"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			theme := Theme{Title: tc.title, Icons: tc.icons}.withDefaults()
+			require.Equal(t, tc.expectedTitles, tc.overview.title(theme))
+		})
+	}
+}
+
+type namedResponse struct {
+	name string
+}
+
+func (nr namedResponse) Indicator() string {
+	return ""
+}
+
+func (nr namedResponse) Name() string {
+	return nr.name
+}
+
+func (nr namedResponse) UpdatedAt() time.Time {
+	return time.Time{}
+}
+
+func (nr namedResponse) URL() string {
+	return "https://test.service/"
+}
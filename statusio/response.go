@@ -0,0 +1,162 @@
+// Package statusio handles communicating with status pages hosted on status.io
+package statusio
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "statusio"
+
+// Status codes used by status.io for the page, its components, and their containers
+const (
+	StatusOperational              = 100
+	StatusPlannedMaintenance       = 200
+	StatusDegradedPerformance      = 300
+	StatusPartialServiceDisruption = 400
+	StatusServiceDisruption        = 500
+	StatusSecurityEvent            = 600
+)
+
+// Indicator maps a status.io status code onto the plugin's severities
+func Indicator(code int) string {
+	switch {
+	case code < StatusPlannedMaintenance:
+		return "none"
+	case code < StatusDegradedPerformance:
+		return status.IndicatorMaintenance
+	case code < StatusServiceDisruption:
+		return "minor"
+	default:
+		return "major"
+	}
+}
+
+// Status is the state of the page, a component, or a container
+type Status struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Updated    time.Time `json:"updated"`
+	Status     string    `json:"status"`
+	StatusCode int       `json:"status_code"`
+}
+
+// Component is a part of the service, made up of containers such as regions
+type Component struct {
+	Status
+	Containers []Status `json:"containers"`
+}
+
+// Incident is an incident open on the page
+type Incident struct {
+	ID   string `json:"_id"`
+	Name string `json:"name"`
+}
+
+// Result is the status of the page
+type Result struct {
+	StatusOverall Status      `json:"status_overall"`
+	Components    []Component `json:"status"`
+	Incidents     []Incident  `json:"incidents"`
+}
+
+// Response is what status.io returns for /1.0/status/{page_id}
+type Response struct {
+	Result Result `json:"result"`
+
+	name    string
+	pageURL string
+}
+
+// Indicator returns the most severe status of the page and its components
+func (r Response) Indicator() string {
+	code := r.Result.StatusOverall.StatusCode
+	for _, c := range r.Result.Components {
+		if c.StatusCode > code {
+			code = c.StatusCode
+		}
+		for _, ct := range c.Containers {
+			if ct.StatusCode > code {
+				code = ct.StatusCode
+			}
+		}
+	}
+
+	return Indicator(code)
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when the page status last changed
+func (r Response) UpdatedAt() time.Time {
+	return r.Result.StatusOverall.Updated
+}
+
+// URL returns the page the status was read from
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists every component with its status, the containers not operational, and the open incidents
+func (r Response) Summary() []string {
+	var lines []string
+
+	components := append([]Component(nil), r.Result.Components...)
+	sort.SliceStable(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+
+	for _, c := range components {
+		lines = append(lines, c.Name+": "+c.Status.Status)
+		for _, ct := range c.Containers {
+			if ct.StatusCode > StatusOperational {
+				lines = append(lines, c.Name+" ("+ct.Name+"): "+ct.Status)
+			}
+		}
+	}
+
+	for _, i := range r.Result.Incidents {
+		lines = append(lines, "Incident: "+i.Name)
+	}
+
+	return lines
+}
+
+// ClientReader implements the Reader interface for status.io pages, e.g. https://api.status.io/1.0/status/{page_id}
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Response
+
+	err = g.GetJSON(cr.PageURL, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Result.StatusOverall.StatusCode == 0 {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, cr.PageURL+" did not report a status")
+	}
+
+	resp.name = cr.ServiceName
+	resp.pageURL = cr.PageURL
+
+	return resp, nil
+}
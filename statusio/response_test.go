@@ -0,0 +1,99 @@
+package statusio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Indicator(t *testing.T) {
+	tests := map[int]string{
+		StatusOperational:              "none",
+		StatusPlannedMaintenance:       status.IndicatorMaintenance,
+		StatusDegradedPerformance:      "minor",
+		StatusPartialServiceDisruption: "minor",
+		StatusServiceDisruption:        "major",
+		StatusSecurityEvent:            "major",
+	}
+	for code, expected := range tests {
+		require.Equal(t, expected, Indicator(code), "status code %d", code)
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- partial service disruption": {
+			reader: ClientReader{ServiceName: "Vendor", PageURL: "https://api.status.io/1.0/status/5e6f7a8b9c0d1e2f3a4b5c6d"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "minor", details.Indicator())
+				require.Equal(t, "Vendor", details.Name())
+				require.Equal(t, "https://api.status.io/1.0/status/5e6f7a8b9c0d1e2f3a4b5c6d", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 52, 11, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"API: Partial Service Disruption",
+					"API (US East): Partial Service Disruption",
+					"Website: Operational",
+					"Incident: Elevated API error rates in US East",
+				}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- operational": {
+			reader: ClientReader{ServiceName: "Dashboard", PageURL: "https://api.status.io/1.0/status/5a1b2c3d4e5f6a7b8c9d0e1f"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Equal(t, []string{"Dashboard: Operational"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"base path- planned maintenance": {
+			reader: ClientReader{ServiceName: "Database", PageURL: "https://api.status.io/1.0/status/5b1b2c3d4e5f6a7b8c9d0e1f"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, status.IndicatorMaintenance, details.Indicator())
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://api.status.io/1.0/status/unknown"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://api.status.io/1.0/status/unknown"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Vendor", PageURL: "https://api.status.io/1.0/status/5e6f7a8b9c0d1e2f3a4b5c6d"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"result":{}}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
@@ -0,0 +1,33 @@
+package statuspageio
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+func FuzzClientReader_ReadStatus(f *testing.F) {
+	f.Add([]byte(`{"page":{"id":"6w4r0ttlx5ft","name":"CircleCI","url":"https://status.circleci.com","time_zone":"America/Los_Angeles","updated_at":"2024-03-04T06:48:29.531-08:00"},"status":{"indicator":"major","description":"Partial System Outage"}}`))
+	f.Add([]byte(`{"page":null,"status":null}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`<!DOCTYPE html><html></html>`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		client := fetch.NewStaticClient(http.StatusOK, "application/json", body)
+
+		details, err := ClientReader{ServiceName: "Fuzz", PageURL: "https://status.example.com/api/v2/status.json"}.ReadStatus(client)
+		if err != nil {
+			require.Nil(t, details)
+			return
+		}
+
+		require.NotNil(t, details)
+		_ = details.Indicator()
+		_ = details.Name()
+		_ = details.UpdatedAt()
+		_ = details.URL()
+	})
+}
@@ -0,0 +1,64 @@
+package statuspageio
+
+import (
+	"testing"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/statuspageio"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+)
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- major outage": {
+			reader: ClientReader{ServiceName: "CircleCI", PageURL: "https://status.circleci.com/api/v2/status.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, "CircleCI", details.Name())
+				require.Equal(t, "Partial System Outage", details.(statuspageio.Response).Status.Description)
+			},
+		},
+		"base path- operational": {
+			reader: ClientReader{ServiceName: "GitHub", PageURL: "https://www.githubstatus.com/api/v2/status.json"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Equal(t, "GitHub", details.Name())
+			},
+		},
+		"exceptional path- HTML instead of JSON": {
+			reader:      ClientReader{ServiceName: "Example", PageURL: "https://status.example.com/login"},
+			expectedErr: glitch.NewDataError(nil, fetch.ErrorHTMLReceived, "https://status.example.com/login returned an HTML page instead of JSON"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://status.unknown.com/api/v2/status.json"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://status.unknown.com/api/v2/status.json"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// Package uptimekuma handles communicating with the public status pages of self-hosted Uptime Kuma instances
+package uptimekuma
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+// ServiceType is the name we use for various checks
+const ServiceType = "uptimekuma"
+
+// ErrorMissingSlug is returned when the site URL does not name a status page
+const ErrorMissingSlug = "MISSING_STATUS_PAGE_SLUG"
+
+// Heartbeat statuses reported by Uptime Kuma
+const (
+	HeartbeatDown        = 0
+	HeartbeatUp          = 1
+	HeartbeatPending     = 2
+	HeartbeatMaintenance = 3
+)
+
+// Incident styles Uptime Kuma pins to a status page; the others are informational
+const (
+	StyleDanger  = "danger"
+	StyleWarning = "warning"
+)
+
+// API paths, relative to where Uptime Kuma is installed
+const (
+	statusPagePath = "/api/status-page/"
+	heartbeatPath  = "/api/status-page/heartbeat/"
+	publicPagePath = "/status/"
+)
+
+// Indicator maps a heartbeat status onto our severities
+func Indicator(heartbeat int) string {
+	switch heartbeat {
+	case HeartbeatDown:
+		return "major"
+	case HeartbeatPending:
+		return "minor"
+	case HeartbeatMaintenance:
+		return status.IndicatorMaintenance
+	default:
+		return "none"
+	}
+}
+
+// severity orders our indicators from least to most severe
+var severity = map[string]int{
+	"none":                      0,
+	status.IndicatorMaintenance: 1,
+	"minor":                     2,
+	"major":                     3,
+}
+
+// Time reads the timestamps Uptime Kuma writes in UTC without a time zone, e.g. 2024-03-04 14:59:00.123
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON handles converting data into the Time type
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+
+	err := json.Unmarshal(data, &s)
+	if err != nil || s == "" {
+		return err
+	}
+
+	parsed, pErr := time.Parse("2006-01-02 15:04:05.999999999", s)
+	if pErr != nil {
+		parsed, pErr = time.Parse(time.RFC3339, s)
+		if pErr != nil {
+			return pErr
+		}
+	}
+
+	t.Time = parsed
+
+	return nil
+}
+
+// Config describes the status page
+type Config struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// Incident is the incident pinned to the status page
+type Incident struct {
+	Title           string `json:"title"`
+	Style           string `json:"style"`
+	LastUpdatedDate Time   `json:"lastUpdatedDate"`
+}
+
+// Maintenance is a maintenance shown on the status page
+type Maintenance struct {
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// Monitor is a monitor shown on the status page
+type Monitor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Group is a section of the status page
+type Group struct {
+	Name        string    `json:"name"`
+	MonitorList []Monitor `json:"monitorList"`
+}
+
+// Page is what Uptime Kuma returns for /api/status-page/{slug}
+type Page struct {
+	Config          Config        `json:"config"`
+	Incident        *Incident     `json:"incident"`
+	PublicGroupList []Group       `json:"publicGroupList"`
+	MaintenanceList []Maintenance `json:"maintenanceList"`
+}
+
+// Heartbeat is a single check of a monitor
+type Heartbeat struct {
+	Status int    `json:"status"`
+	Time   Time   `json:"time"`
+	Msg    string `json:"msg"`
+}
+
+// Heartbeats is what Uptime Kuma returns for /api/status-page/heartbeat/{slug}: the recent checks of every monitor by
+// its ID, oldest first
+type Heartbeats struct {
+	HeartbeatList map[string][]Heartbeat `json:"heartbeatList"`
+}
+
+// MonitorStatus is a monitor along with its latest heartbeat; monitors without one have not been checked yet
+type MonitorStatus struct {
+	Monitor
+	Latest *Heartbeat
+}
+
+// Indicator returns the status of the monitor's latest heartbeat
+func (ms MonitorStatus) Indicator() string {
+	if ms.Latest == nil {
+		return "none"
+	}
+
+	return Indicator(ms.Latest.Status)
+}
+
+// StatusText describes the monitor's latest heartbeat
+func (ms MonitorStatus) StatusText() string {
+	if ms.Latest == nil {
+		return "No data"
+	}
+
+	switch ms.Latest.Status {
+	case HeartbeatDown:
+		return "Down"
+	case HeartbeatPending:
+		return "Pending"
+	case HeartbeatMaintenance:
+		return "Maintenance"
+	default:
+		return "Up"
+	}
+}
+
+// Response holds the monitors of an Uptime Kuma status page, rolled up into one service
+type Response struct {
+	Page     Page
+	Monitors []MonitorStatus
+
+	name    string
+	pageURL string
+}
+
+// Indicator returns the most severe status of the monitors and the incident pinned to the page
+func (r Response) Indicator() string {
+	indicator := "none"
+	if r.Page.Incident != nil {
+		switch r.Page.Incident.Style {
+		case StyleDanger:
+			indicator = "major"
+		case StyleWarning:
+			indicator = "minor"
+		}
+	}
+
+	for _, m := range r.Monitors {
+		if i := m.Indicator(); severity[i] > severity[indicator] {
+			indicator = i
+		}
+	}
+
+	return indicator
+}
+
+// Name returns the name the site was given in the configuration
+func (r Response) Name() string {
+	return r.name
+}
+
+// UpdatedAt returns when a monitor last reported or the pinned incident last changed
+func (r Response) UpdatedAt() time.Time {
+	var latest time.Time
+	if r.Page.Incident != nil {
+		latest = r.Page.Incident.LastUpdatedDate.Time
+	}
+
+	for _, m := range r.Monitors {
+		if m.Latest != nil && m.Latest.Time.After(latest) {
+			latest = m.Latest.Time.Time
+		}
+	}
+
+	return latest
+}
+
+// URL returns the public status page
+func (r Response) URL() string {
+	return r.pageURL
+}
+
+// Summary lists the monitors with their status, then the pinned incident and any maintenance
+func (r Response) Summary() []string {
+	var lines []string
+	for _, m := range r.Monitors {
+		lines = append(lines, m.Name+": "+m.StatusText())
+	}
+
+	if r.Page.Incident != nil && r.Page.Incident.Title != "" {
+		lines = append(lines, "Incident: "+r.Page.Incident.Title)
+	}
+	for _, m := range r.Page.MaintenanceList {
+		lines = append(lines, "Maintenance: "+m.Title)
+	}
+
+	return lines
+}
+
+// Expand lists every monitor as a service of its own, named after the site and the monitor
+func (r Response) Expand() []whatsupstatus.Details {
+	parts := make([]whatsupstatus.Details, 0, len(r.Monitors))
+	for _, m := range r.Monitors {
+		part := status.Part{
+			PartName:      r.name + " / " + m.Name,
+			PartIndicator: m.Indicator(),
+			PartURL:       r.pageURL,
+		}
+		if m.Latest != nil {
+			part.PartUpdatedAt = m.Latest.Time.Time
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// ClientReader implements the Reader interface for Uptime Kuma status pages. PageURL is the public status page, e.g.
+// https://kuma.example.com/status/{slug}, or its API, e.g. https://kuma.example.com/api/status-page/{slug}.
+type ClientReader struct {
+	ServiceName string
+	PageURL     string
+}
+
+// ReadStatus handles communicating with the service to get its status details
+func (cr ClientReader) ReadStatus(client whatsup.StatusPageClient) (whatsupstatus.Details, glitch.DataError) {
+	g, err := fetch.AsGetter(client, ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	base, slug, err := cr.page()
+	if err != nil {
+		return nil, err
+	}
+
+	var page Page
+
+	err = g.GetJSON(base+statusPagePath+slug, &page)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Config.Slug == "" {
+		return nil, glitch.NewDataError(nil, fetch.ErrorUnableToParseResponse, base+statusPagePath+slug+" is not an Uptime Kuma status page")
+	}
+
+	var heartbeats Heartbeats
+
+	err = g.GetJSON(base+heartbeatPath+slug, &heartbeats)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := Response{
+		Page:    page,
+		name:    cr.ServiceName,
+		pageURL: base + publicPagePath + slug,
+	}
+
+	for _, group := range page.PublicGroupList {
+		for _, m := range group.MonitorList {
+			ms := MonitorStatus{Monitor: m}
+			if beats := heartbeats.HeartbeatList[strconv.Itoa(m.ID)]; len(beats) > 0 {
+				sort.SliceStable(beats, func(i, j int) bool { return beats[i].Time.Before(beats[j].Time.Time) })
+				ms.Latest = &beats[len(beats)-1]
+			}
+			resp.Monitors = append(resp.Monitors, ms)
+		}
+	}
+
+	return resp, nil
+}
+
+// page returns where Uptime Kuma is installed and the slug of the status page
+func (cr ClientReader) page() (string, string, glitch.DataError) {
+	u, pErr := url.Parse(cr.PageURL)
+	if pErr != nil {
+		return "", "", glitch.NewDataError(pErr, ErrorMissingSlug, "invalid Uptime Kuma URL "+cr.PageURL)
+	}
+
+	for _, prefix := range []string{statusPagePath, publicPagePath} {
+		if before, slug, found := strings.Cut(u.Path, prefix); found && slug != "" && !strings.Contains(slug, "/") {
+			u.Path, u.RawQuery, u.Fragment = before, "", ""
+			return strings.TrimSuffix(u.String(), "/"), url.PathEscape(slug), nil
+		}
+	}
+
+	return "", "", glitch.NewDataError(nil, ErrorMissingSlug, cr.ServiceName+" must point at an Uptime Kuma status page, e.g. https://kuma.example.com/status/{slug}")
+}
@@ -0,0 +1,112 @@
+package uptimekuma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sprak3000/go-glitch/glitch"
+	whatsupstatus "github.com/sprak3000/go-whatsup-client/status"
+	"github.com/sprak3000/go-whatsup-client/whatsup/clientmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/status"
+)
+
+func TestUnit_Indicator(t *testing.T) {
+	tests := map[int]string{
+		HeartbeatDown:        "major",
+		HeartbeatUp:          "none",
+		HeartbeatPending:     "minor",
+		HeartbeatMaintenance: status.IndicatorMaintenance,
+	}
+	for heartbeat, expected := range tests {
+		require.Equal(t, expected, Indicator(heartbeat), "heartbeat %d", heartbeat)
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus(t *testing.T) {
+	client := fetch.NewReplayClient("testdata")
+
+	tests := map[string]struct {
+		reader      ClientReader
+		expectedErr glitch.DataError
+		validate    func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError)
+	}{
+		"base path- public status page": {
+			reader: ClientReader{ServiceName: "Internal", PageURL: "https://kuma.example.com/status/internal"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "major", details.Indicator())
+				require.Equal(t, "Internal", details.Name())
+				require.Equal(t, "https://kuma.example.com/status/internal", details.URL())
+				require.Equal(t, time.Date(2024, time.March, 4, 14, 59, 30, 0, time.UTC), details.UpdatedAt())
+				require.Equal(t, []string{
+					"API: Up",
+					"Wiki: Maintenance",
+					"Database: Down",
+					"New Monitor: No data",
+					"Incident: Intermittent VPN drops",
+					"Maintenance: Wiki upgrade",
+				}, details.(status.Summarizer).Summary())
+
+				var parts []string
+				for _, p := range details.(status.Expander).Expand() {
+					parts = append(parts, p.Name()+" "+p.Indicator())
+				}
+				require.Equal(t, []string{
+					"Internal / API none",
+					"Internal / Wiki maintenance",
+					"Internal / Database major",
+					"Internal / New Monitor none",
+				}, parts)
+			},
+		},
+		"base path- status page API": {
+			reader: ClientReader{ServiceName: "Public", PageURL: "https://kuma.example.com/api/status-page/public"},
+			validate: func(t *testing.T, details whatsupstatus.Details, _, actualErr glitch.DataError) {
+				require.NoError(t, actualErr)
+				require.Equal(t, "none", details.Indicator())
+				require.Equal(t, "https://kuma.example.com/status/public", details.URL())
+				require.Equal(t, []string{"Website: Up"}, details.(status.Summarizer).Summary())
+			},
+		},
+		"exceptional path- no slug": {
+			reader:      ClientReader{ServiceName: "Kuma", PageURL: "https://kuma.example.com/dashboard"},
+			expectedErr: glitch.NewDataError(nil, ErrorMissingSlug, "Kuma must point at an Uptime Kuma status page, e.g. https://kuma.example.com/status/{slug}"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Equal(t, expectedErr, actualErr)
+				require.Nil(t, details)
+			},
+		},
+		"exceptional path- nothing recorded": {
+			reader:      ClientReader{ServiceName: "Unknown", PageURL: "https://kuma.example.com/status/unknown"},
+			expectedErr: glitch.NewDataError(nil, whatsupstatus.ErrorUnableToMakeClientRequest, "unable to request https://kuma.example.com/api/status-page/unknown"),
+			validate: func(t *testing.T, details whatsupstatus.Details, expectedErr, actualErr glitch.DataError) {
+				require.Error(t, actualErr)
+				require.Equal(t, expectedErr.Code(), actualErr.Code())
+				require.Nil(t, details)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			details, err := tc.reader.ReadStatus(client)
+			tc.validate(t, details, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUnit_ClientReader_ReadStatus_Errors(t *testing.T) {
+	reader := ClientReader{ServiceName: "Internal", PageURL: "https://kuma.example.com/status/internal"}
+
+	details, err := reader.ReadStatus(fetch.NewStaticClient(200, "application/json", []byte(`{"ok":false,"msg":"Status page not found"}`)))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnableToParseResponse, err.Code())
+
+	ctrl := gomock.NewController(t)
+	details, err = reader.ReadStatus(clientmock.NewMockStatusPageClient(ctrl))
+	require.Nil(t, details)
+	require.Equal(t, fetch.ErrorUnsupportedClient, err.Code())
+}
@@ -9,25 +9,391 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/sprak3000/go-whatsup-client/whatsup"
+	"github.com/sprak3000/go-glitch/glitch"
 
 	"github.com/sprak3000/xbar-whats-up/configuration"
+	"github.com/sprak3000/xbar-whats-up/debug"
+	"github.com/sprak3000/xbar-whats-up/fetch"
+	"github.com/sprak3000/xbar-whats-up/history"
+	"github.com/sprak3000/xbar-whats-up/mute"
 	"github.com/sprak3000/xbar-whats-up/service"
+	"github.com/sprak3000/xbar-whats-up/status"
 )
 
+// Files kept next to the configuration file
+const (
+	muteFilename    = ".whats-up.mute.json"
+	historyFilename = ".whats-up.history.jsonl"
+	cacheFilename   = ".whats-up.cache.json"
+	remoteFilename  = ".whats-up.remote.json"
+)
+
+// paths holds where the plugin keeps its files
+type paths struct {
+	dir     string
+	config  string
+	mute    string
+	history string
+	cache   string
+	remote  string
+}
+
+func newPaths(config string) paths {
+	dir := filepath.Dir(config)
+
+	return paths{
+		dir:     dir,
+		config:  config,
+		mute:    filepath.Join(dir, muteFilename),
+		history: filepath.Join(dir, historyFilename),
+		cache:   filepath.Join(dir, cacheFilename),
+		remote:  filepath.Join(dir, remoteFilename),
+	}
+}
+
 func main() {
-	sites, lErr := service.LoadSites(configuration.FileReader{}, configuration.FileWriter{}, "./.whats-up.json")
+	configFlag, args := configuration.ParseArgs(os.Args[1:])
+	format, args := debug.ParseArgs(args, os.Getenv)
+	finder := configuration.NewFinder()
+
+	if len(args) > 0 && args[0] == "init" {
+		exitOnError(runInit(finder, configFlag))
+		return
+	}
+
+	configPath, found := finder.Find(configFlag)
+	if !found {
+		err := glitch.NewDataError(nil, service.ErrorConfigurationNotFound, "no What's Up configuration found in "+
+			strings.Join(finder.Candidates(configFlag), ", ")+"; run `"+filepath.Base(os.Args[0])+" init` to create one")
+		if len(args) > 0 {
+			exitOnError(err)
+		}
+		displayError(err)
+	}
+	p := newPaths(configPath)
+
+	logger, closeLog := openDebugLog(format, p.dir)
+	defer closeLog()
+
+	if len(args) > 0 {
+		var err glitch.DataError
+
+		switch args[0] {
+		case "stats":
+			err = runStats(p, args[1:])
+		case "record":
+			err = runRecord(p, args[1:], logger)
+		case "config":
+			err = runConfig(p, args[1:], logger)
+		case "migrate":
+			err = runMigrate(p, args[1:])
+		case status.CommandSnooze, status.CommandAcknowledge, status.CommandUnmute:
+			err = runMuteCommand(p, args)
+		default:
+			err = glitch.NewDataError(nil, ErrorUnknownCommand, "unknown command "+args[0]+"; use init, stats, record, "+
+				"config, migrate, "+status.CommandSnooze+", "+status.CommandAcknowledge+", or "+status.CommandUnmute)
+		}
+
+		if err != nil {
+			closeLog()
+		}
+		exitOnError(err)
+		return
+	}
+
+	reader := newConfigReader(p, logger)
+	config, lErr := service.LoadConfig(reader, nil, p.config)
 	if lErr != nil {
-		fmt.Println("What's Up Error")
-		fmt.Println("---")
-		fmt.Printf("%v\n", lErr.Error())
-		os.Exit(1)
+		displayError(lErr)
+	}
+
+	state, mErr := mute.Load(configuration.FileReader{}, p.mute)
+	if mErr != nil {
+		displayError(mErr)
+	}
+
+	// A cache we cannot read only costs us full downloads; it is replaced when saved
+	cache, _ := fetch.LoadCache(configuration.FileReader{}, p.cache)
+
+	c := fetch.NewClient(&http.Client{Timeout: fetch.DefaultTimeout}).WithOptions(config.HTTP)
+	c.Cache = cache
+	c.Logger = logger
+	now := time.Now()
+
+	overview := config.Sites.GetOverview(c)
+	polls := recordHistory(p, config.Sites, overview, now)
+
+	if fallback, ok := reader.Fallback(); ok {
+		overview.Warnings = append(overview.Warnings, "Using the copy of the remote configuration from "+
+			fallback.FetchedAt.Local().Format("2006 Jan 02 15:04")+"; "+fallback.URL+" is unreachable")
+	}
+
+	overview = state.Apply(overview, now)
+	overview.Theme = config.Display.Resolve(os.Getenv("XBARDarkMode") == "true")
+	overview.PluginPath, _ = os.Executable()
+	overview.Theme.ErrorDetails = overview.Theme.ErrorDetails || logger != nil
+	if overview.Theme.Stats != "" {
+		overview.Reliability = polls.Report(now).Window(overview.Theme.Stats)
+	}
+	overview.Display(os.Stdout)
+
+	// Entries that no longer apply were dropped while applying them
+	_ = state.Save(configuration.FileWriter{}, p.mute)
+	if logger != nil {
+		logger.Info("cache", "hit_rate", cache.Run.HitRate(), "fresh", cache.Run.Fresh, "revalidated", cache.Run.Revalidated, "downloaded", cache.Run.Downloaded)
+	}
+	_ = cache.Save(configuration.FileWriter{}, p.cache)
+}
+
+// newConfigReader returns the reader for the configuration file, fetching any remote configuration it points at
+func newConfigReader(p paths, logger *slog.Logger) *fetch.RemoteReader {
+	c := fetch.NewClient(&http.Client{Timeout: fetch.DefaultTimeout})
+	c.Logger = logger
+
+	return &fetch.RemoteReader{
+		Local:        configuration.FileReader{},
+		Writer:       configuration.FileWriter{},
+		Client:       c,
+		CopyFilename: p.remote,
+	}
+}
+
+// openDebugLog returns the debug logger when debugging is on, or nil; the returned function closes the log file
+func openDebugLog(format, dir string) (*slog.Logger, func()) {
+	if format == "" {
+		return nil, func() {}
+	}
+
+	logger, closer, err := debug.Open(format, dir, os.Getenv)
+	if err != nil {
+		// Debugging should never stop the plugin from running
+		return nil, func() {}
+	}
+
+	return logger, func() { _ = closer.Close() }
+}
+
+// recordHistory adds the statuses from this run to the history; a history we cannot read is left untouched
+func recordHistory(p paths, sites service.Sites, overview status.Overview, now time.Time) history.Polls {
+	polls, err := history.Load(configuration.FileReader{}, p.history)
+	if err != nil {
+		return nil
+	}
+
+	polls = append(polls, sites.Polls(overview, now)...).Prune(now)
+	_ = polls.Save(configuration.FileWriter{}, p.history)
+
+	return polls
+}
+
+func runStats(p paths, args []string) glitch.DataError {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := fs.String("format", history.FormatTable, "output format: table, json, or csv")
+	window := fs.String("window", "", "only report on this window: 24h, 7d, or 30d")
+	_ = fs.Parse(args)
+
+	polls, err := history.Load(configuration.FileReader{}, p.history)
+	if err != nil {
+		return err
+	}
+
+	report := polls.Report(time.Now())
+	if *window != "" {
+		var filtered history.Report
+		for _, s := range report {
+			if s.Window == *window {
+				filtered = append(filtered, s)
+			}
+		}
+		report = filtered
+	}
+
+	err = report.Write(os.Stdout, *format)
+	if err != nil || *format != history.FormatTable {
+		return err
+	}
+
+	cache, cErr := fetch.LoadCache(configuration.FileReader{}, p.cache)
+	if cErr == nil {
+		fmt.Printf("\nHTTP cache: %s\n", cache.Stats)
+	}
+
+	return nil
+}
+
+// ErrorUnableToRecord is returned when the fixture directory cannot be created
+const ErrorUnableToRecord = "UNABLE_TO_RECORD"
+
+// runRecord saves the raw responses of every configured site into a fixture directory for replaying in tests
+func runRecord(p paths, args []string, logger *slog.Logger) glitch.DataError {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	dir := fs.String("dir", "./fixtures", "directory to save the responses into")
+	_ = fs.Parse(args)
+
+	config, err := service.LoadConfig(newConfigReader(p, logger), nil, p.config)
+	if err != nil {
+		return err
+	}
+
+	c, rErr := fetch.NewRecordingClient(*dir)
+	if rErr != nil {
+		return glitch.NewDataError(rErr, ErrorUnableToRecord, "unable to create fixture directory "+*dir)
+	}
+
+	c.Logger = logger
+	overview := config.Sites.GetOverview(c.WithOptions(config.HTTP))
+	for _, e := range overview.Errors {
+		fmt.Printf("%s: %v\n", e.ServiceName, e.Error.Error())
+	}
+	fmt.Printf("Recorded %d sites into %s\n", len(config.Sites)-len(overview.Errors), *dir)
+
+	return nil
+}
+
+// ErrorUnknownCommand is returned for commands we do not know
+const ErrorUnknownCommand = "UNKNOWN_COMMAND"
+
+// ErrorUnknownConfigCommand is returned for config subcommands we do not know
+const ErrorUnknownConfigCommand = "UNKNOWN_CONFIG_COMMAND"
+
+// runConfig prints the configuration file, or with --resolved, the configuration after merging its includes and local
+// overrides along with where each entry came from
+func runConfig(p paths, args []string, logger *slog.Logger) glitch.DataError {
+	if len(args) == 0 || args[0] != "show" {
+		return glitch.NewDataError(nil, ErrorUnknownConfigCommand, "usage: config show [--resolved]")
+	}
+
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	resolved := fs.Bool("resolved", false, "print the merged configuration and the files each entry came from")
+	_ = fs.Parse(args[1:])
+
+	if !*resolved {
+		data, err := os.ReadFile(p.config)
+		if err != nil {
+			return glitch.NewDataError(err, service.ErrorConfigurationNotFound, "no What's Up configuration at "+p.config)
+		}
+
+		fmt.Printf("# %s\n%s\n", p.config, strings.TrimRight(string(data), "\n"))
+		return nil
+	}
+
+	r, err := service.ResolveConfig(newConfigReader(p, logger), p.config)
+	if err != nil {
+		return err
+	}
+
+	wErr := r.Write(os.Stdout)
+	if wErr != nil {
+		return glitch.NewDataError(wErr, service.ErrorUnableToParseConfiguration, "unable to print the resolved configuration")
 	}
 
-	c := whatsup.NewStatusPageClient()
+	return nil
+}
+
+// ErrorUnableToMigrate is returned when a migrated configuration file or its backup cannot be written
+const ErrorUnableToMigrate = "UNABLE_TO_MIGRATE"
+
+// runMigrate upgrades a configuration file -- the one in use unless another is given -- to the current schema, keeping
+// the original next to it with a .bak extension
+func runMigrate(p paths, args []string) glitch.DataError {
+	filename := p.config
+	if len(args) > 0 {
+		filename = args[0]
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return glitch.NewDataError(err, service.ErrorConfigurationNotFound, "no What's Up configuration at "+filename)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return glitch.NewDataError(err, service.ErrorConfigurationNotFound, "no What's Up configuration at "+filename)
+	}
+
+	migrated, changed, mErr := service.Migrate(data)
+	if mErr != nil {
+		return mErr
+	}
+
+	if !changed {
+		fmt.Printf("%s already uses version %d of the configuration\n", filename, service.ConfigVersion)
+		return nil
+	}
+
+	backup := filename + ".bak"
+	err = os.WriteFile(backup, data, info.Mode().Perm())
+	if err != nil {
+		return glitch.NewDataError(err, ErrorUnableToMigrate, "unable to back up "+filename+" to "+backup)
+	}
+
+	err = os.WriteFile(filename, migrated, info.Mode().Perm())
+	if err != nil {
+		return glitch.NewDataError(err, ErrorUnableToMigrate, "unable to write the migrated configuration to "+filename)
+	}
+
+	fmt.Printf("Migrated %s to version %d of the configuration; the original is in %s\n", filename, service.ConfigVersion, backup)
+
+	return nil
+}
+
+func runMuteCommand(p paths, args []string) glitch.DataError {
+	state, err := mute.Load(configuration.FileReader{}, p.mute)
+	if err != nil {
+		return err
+	}
+
+	err = state.Run(args, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return state.Save(configuration.FileWriter{}, p.mute)
+}
+
+// runInit creates an empty configuration file where the plugin looks for it first, unless one already exists there
+func runInit(finder configuration.Finder, configFlag string) glitch.DataError {
+	path := finder.Default(configFlag)
+	if finder.Exists(path) {
+		fmt.Printf("What's Up configuration already exists at %s\n", path)
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return glitch.NewDataError(err, service.ErrorUnableToWriteDefaultConfiguration, "unable to create the directory for "+path)
+	}
+
+	_, lErr := service.LoadConfig(configuration.FileReader{}, configuration.FileWriter{}, path)
+	if lErr != nil {
+		return lErr
+	}
+
+	fmt.Printf("Created What's Up configuration at %s\n", path)
+
+	return nil
+}
+
+// exitOnError prints the error of a command and exits
+func exitOnError(err glitch.DataError) {
+	if err != nil {
+		fmt.Printf("%v\n", err.Error())
+		os.Exit(1)
+	}
+}
 
-	sites.GetOverview(c).Display(os.Stdout)
+func displayError(err glitch.DataError) {
+	fmt.Println("What's Up Error")
+	fmt.Println("---")
+	fmt.Printf("%v\n", err.Error())
+	os.Exit(1)
 }